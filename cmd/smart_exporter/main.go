@@ -0,0 +1,62 @@
+// Copyright 2017-18 Daniel Swarbrick. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Command smart_exporter runs smartctl's device discovery and SMART polling as a long-running
+// Prometheus/OpenMetrics exporter, rather than a one-shot CLI report.
+package main
+
+import (
+	"flag"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	"github.com/dswarbrick/smart/exporter"
+
+	// Blank-imported for their self-registration with the transport package.
+	_ "github.com/dswarbrick/smart/transport/aacraid"
+	_ "github.com/dswarbrick/smart/transport/areca"
+	_ "github.com/dswarbrick/smart/transport/cciss"
+	_ "github.com/dswarbrick/smart/transport/megaraid"
+)
+
+func main() {
+	scanInterval := flag.Duration("scan-interval", time.Minute, "Interval between SMART device scans")
+	listen := flag.String("listen", ":9633", "Address on which to expose /metrics")
+	raidFlag := flag.Bool("raid", false, "Also poll physical drives behind MegaRAID/Areca/cciss/aacraid host adapters")
+	flag.Parse()
+
+	exp := exporter.New(*scanInterval, *raidFlag)
+	prometheus.MustRegister(exp)
+
+	stop := make(chan struct{})
+	go exp.Run(stop)
+
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, os.Interrupt)
+	go func() {
+		<-sig
+		close(stop)
+		os.Exit(0)
+	}()
+
+	http.Handle("/metrics", promhttp.Handler())
+	log.Printf("smart_exporter listening on %s (scan interval %s)", *listen, *scanInterval)
+	log.Fatal(http.ListenAndServe(*listen, nil))
+}
@@ -0,0 +1,65 @@
+// Copyright 2017-18 Daniel Swarbrick. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build linux
+// +build linux
+
+package main
+
+import (
+	"fmt"
+
+	"github.com/syndtr/gocapability/capability"
+
+	"github.com/dswarbrick/smart/smart/caps"
+)
+
+// checkCaps warns if none of caps.Required() are in the calling process's effective set. Note
+// that this depends on the binary having the capabilities set (i.e., via -install-caps or the
+// `setcap` utility), and on VFS support. Alternatively, if the binary is executed as root, it
+// automatically has all capabilities set.
+func checkCaps() {
+	c, err := capability.NewPid2(0)
+	if err != nil {
+		fmt.Println("capability.NewPid2:", err)
+		return
+	}
+
+	if err := c.Load(); err != nil {
+		fmt.Println("capability.Load:", err)
+		return
+	}
+
+	for _, cap := range caps.Required() {
+		if c.Get(capability.EFFECTIVE, cap) {
+			return
+		}
+	}
+
+	fmt.Println("None of", caps.Required(), "are in effect. Device access will probably fail.")
+}
+
+// installCaps sets caps.Required() as file capabilities on path via cap_set_file, so the binary
+// can be run by unprivileged users without them having to invoke setcap manually. The caller must
+// be running as root (e.g. via sudo or pkexec) for this to succeed.
+func installCaps(path string) error {
+	c, err := capability.NewFile2(path)
+	if err != nil {
+		return err
+	}
+
+	c.Set(capability.EFFECTIVE|capability.PERMITTED, caps.Required()...)
+
+	return c.Apply(capability.CAPS)
+}
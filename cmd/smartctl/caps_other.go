@@ -0,0 +1,30 @@
+// Copyright 2017-18 Daniel Swarbrick. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !linux
+// +build !linux
+
+package main
+
+import "errors"
+
+// checkCaps is a no-op on platforms without POSIX capabilities. Device access on these
+// platforms is instead gated by the OS's own privilege model (e.g. Administrator on Windows,
+// root or the "com.apple.private.iokit" entitlements on Darwin).
+func checkCaps() {}
+
+// installCaps is unsupported on platforms without POSIX file capabilities.
+func installCaps(path string) error {
+	return errors.New("smartctl: -install-caps is only supported on Linux")
+}
@@ -13,84 +13,64 @@
 // limitations under the License.
 
 // Go SMART library smartctl reference implementation.
-//
 package main
 
 import (
 	"bytes"
+	"encoding/json"
 	"flag"
 	"fmt"
 	"os"
 	"runtime"
 	"strings"
-	"unsafe"
-
-	"golang.org/x/sys/unix"
 
-	"github.com/dswarbrick/smart"
-	"github.com/dswarbrick/smart/cmd/smartctl/smartdb"
 	"github.com/dswarbrick/smart/drivedb"
-	"github.com/dswarbrick/smart/megaraid"
 	"github.com/dswarbrick/smart/nvme"
 	"github.com/dswarbrick/smart/scsi"
+	"github.com/dswarbrick/smart/smart"
+	"github.com/dswarbrick/smart/smartdb"
+	"github.com/dswarbrick/smart/transport"
+
+	// Blank-imported for their self-registration with the transport package.
+	_ "github.com/dswarbrick/smart/transport/aacraid"
+	_ "github.com/dswarbrick/smart/transport/areca"
+	_ "github.com/dswarbrick/smart/transport/cciss"
+	_ "github.com/dswarbrick/smart/transport/megaraid"
 )
 
-const (
-	_LINUX_CAPABILITY_VERSION_3 = 0x20080522
-
-	CAP_SYS_RAWIO = 1 << 17
-	CAP_SYS_ADMIN = 1 << 21
-)
-
-type capHeader struct {
-	version uint32
-	pid     int
-}
-
-type capData struct {
-	effective   uint32
-	permitted   uint32
-	inheritable uint32
-}
-
-type capsV3 struct {
-	hdr  capHeader
-	data [2]capData
-}
-
-// checkCaps invokes the capget syscall to check for necessary capabilities. Note that this depends
-// on the binary having the capabilities set (i.e., via the `setcap` utility), and on VFS support.
-// Alternatively, if the binary is executed as root, it automatically has all capabilities set.
-func checkCaps() {
-	caps := new(capsV3)
-	caps.hdr.version = _LINUX_CAPABILITY_VERSION_3
-
-	// Use RawSyscall since we do not expect it to block
-	_, _, e1 := unix.RawSyscall(unix.SYS_CAPGET, uintptr(unsafe.Pointer(&caps.hdr)), uintptr(unsafe.Pointer(&caps.data)), 0)
-	if e1 != 0 {
-		fmt.Println("capget() failed:", e1.Error())
-		return
+func scanDevices() {
+	for _, device := range smart.ScanDevices() {
+		fmt.Printf("%#v\n", device)
 	}
 
-	if (caps.data[0].effective&CAP_SYS_RAWIO == 0) && (caps.data[0].effective&CAP_SYS_ADMIN == 0) {
-		fmt.Println("Neither cap_sys_rawio nor cap_sys_admin are in effect. Device access will probably fail.")
+	// Scan every registered RAID/HBA passthrough driver for attached physical drives.
+	for _, d := range transport.Drivers() {
+		targets, err := d.Scan()
+		if err != nil {
+			fmt.Printf("%s: %v\n", d.Name(), err)
+			continue
+		}
+		for _, target := range targets {
+			fmt.Printf("%s: %#v\n", d.Name(), target)
+		}
 	}
 }
 
-func scanDevices() {
-	for _, device := range smart.ScanDevices() {
-		fmt.Printf("%#v\n", device)
+// printOrPrintJSON reads d's SMART data via db and either prints it as indented JSON (when
+// jsonOutput is set) or writes the human-readable PrintSMART report to stdout.
+func printOrPrintJSON(d scsi.Device, db *drivedb.DriveDb, jsonOutput bool) error {
+	if !jsonOutput {
+		return d.PrintSMART(db, os.Stdout)
 	}
 
-	// Open megaraid_sas ioctl device and scan for hosts / devices
-	if m, err := megaraid.CreateMegasasIoctl(); err == nil {
-		defer m.Close()
-		for _, device := range m.ScanDevices() {
-			fmt.Printf("%#v\n", device)
-		}
+	report, err := d.SMART(db)
+	if err != nil {
+		return err
 	}
 
-	//smart.MegaScan()
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(report)
 }
 
 func main() {
@@ -98,10 +78,28 @@ func main() {
 	fmt.Printf("Built with %s on %s (%s)\n\n", runtime.Version(), runtime.GOOS, runtime.GOARCH)
 
 	device := flag.String("device", "", "SATA / NVMe device from which to read SMART attributes, e.g., /dev/sda, /dev/nvme0")
-	megaraidDev := flag.String("megaraid", "", "MegaRAID host and device ID from which to read SMART attributes, e.g., megaraid0_23")
+	raidDevice := flag.String("raid-device", "", "RAID/HBA-attached physical drive from which to read SMART attributes, addressed as <driver><host>/<disk>, e.g. megaraid0/23, areca0/3")
 	scan := flag.Bool("scan", false, "Scan for drives that support SMART")
+	jsonOutput := flag.Bool("json", false, "Print SMART report for -device or -raid-device as JSON instead of a human-readable report")
+	installCapsFlag := flag.Bool("install-caps", false, "Set the required POSIX file capabilities on this binary (run as root) and exit")
 	flag.Parse()
 
+	if *installCapsFlag {
+		exe, err := os.Executable()
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+
+		if err := installCaps(exe); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+
+		fmt.Println("Installed capabilities on", exe)
+		return
+	}
+
 	checkCaps()
 
 	if *device != "" {
@@ -130,22 +128,35 @@ func main() {
 			os.Exit(1)
 		}
 
-		if err := d.PrintSMART(&db, os.Stdout); err != nil {
+		if err := printOrPrintJSON(d, &db, *jsonOutput); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+	} else if *raidDevice != "" {
+		drv, target, err := transport.ParseDeviceSpec(*raidDevice)
+		if err != nil {
 			fmt.Println(err)
 			os.Exit(1)
 		}
-	} else if *megaraidDev != "" {
-		var (
-			host uint16
-			disk uint8
-		)
 
-		if _, err := fmt.Sscanf(*megaraidDev, "megaraid%d_%d", &host, &disk); err != nil {
-			fmt.Println("Invalid MegaRAID host / device ID syntax")
+		d, err := drv.Open(target)
+		if err != nil {
+			fmt.Println(err)
 			os.Exit(1)
 		}
 
-		megaraid.OpenMegasasIoctl(host, disk)
+		defer d.Close()
+
+		db, err := drivedb.OpenDriveDbFromReader(bytes.NewBuffer(smartdb.MustAsset("drivedb.yaml")))
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+
+		if err := printOrPrintJSON(d, &db, *jsonOutput); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
 	} else if *scan {
 		scanDevices()
 	} else {
@@ -0,0 +1,96 @@
+// Copyright 2017-18 Daniel Swarbrick. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package drivedb parses the smartmontools drivedb.yaml, which maps drive model / firmware
+// patterns to vendor-specific SMART attribute names and presentation hints.
+package drivedb
+
+import (
+	"io"
+	"io/ioutil"
+	"regexp"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+// Attribute describes how a single vendor-specific SMART attribute ID should be presented
+// (name and display conversion), as found in a drivedb.yaml entry's "presets" field.
+type Attribute struct {
+	ID         int    `yaml:"id"`
+	Name       string `yaml:"name"`
+	Conversion string `yaml:"conv"`
+}
+
+// Entry is a single drivedb.yaml record, matched by Lookup against a drive's model name to
+// determine which Attribute overrides apply. FirmwareRegex is parsed but not used in matching.
+type Entry struct {
+	Family        string      `yaml:"family"`
+	ModelRegex    string      `yaml:"model_regex"`
+	FirmwareRegex string      `yaml:"firmware_regex"`
+	WarningMsg    string      `yaml:"warning"`
+	Presets       []Attribute `yaml:"presets"`
+
+	modelRe *regexp.Regexp
+}
+
+// DriveDb is a parsed drivedb.yaml, ready to be queried via Lookup.
+type DriveDb struct {
+	Entries []Entry
+}
+
+// OpenDriveDbFromReader reads and parses a drivedb.yaml document from r.
+func OpenDriveDbFromReader(r io.Reader) (DriveDb, error) {
+	var db DriveDb
+
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		return db, err
+	}
+
+	if err := yaml.Unmarshal(b, &db.Entries); err != nil {
+		return db, err
+	}
+
+	for i := range db.Entries {
+		if db.Entries[i].ModelRegex == "" {
+			continue
+		}
+		if re, err := regexp.Compile(db.Entries[i].ModelRegex); err == nil {
+			db.Entries[i].modelRe = re
+		}
+	}
+
+	return db, nil
+}
+
+// AttributeName returns the vendor-specific name for SMART attribute id from e's presets, or
+// "Unknown_Attribute" if e has no preset for it.
+func (e Entry) AttributeName(id uint8) string {
+	for _, preset := range e.Presets {
+		if preset.ID == int(id) {
+			return preset.Name
+		}
+	}
+	return "Unknown_Attribute"
+}
+
+// Lookup returns the drivedb entry whose model_regex matches model, or false if none do.
+func (d *DriveDb) Lookup(model string) (Entry, bool) {
+	for _, e := range d.Entries {
+		if e.modelRe != nil && e.modelRe.MatchString(model) {
+			return e, true
+		}
+	}
+	return Entry{}, false
+}
@@ -0,0 +1,91 @@
+// Copyright 2017-18 Daniel Swarbrick. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package drivedb
+
+import (
+	"strings"
+	"testing"
+)
+
+const testDriveDbYAML = `
+- family: "Seagate Barracuda"
+  model_regex: "^ST[0-9]+DM[0-9]+$"
+  presets:
+    - id: 5
+      name: Reallocated_Sector_Ct
+    - id: 187
+      name: Reported_Uncorrect
+- family: "Samsung SSD"
+  model_regex: "^Samsung SSD"
+  presets:
+    - id: 5
+      name: Retired_Block_Count
+`
+
+func TestDriveDbLookup(t *testing.T) {
+	db, err := OpenDriveDbFromReader(strings.NewReader(testDriveDbYAML))
+	if err != nil {
+		t.Fatalf("OpenDriveDbFromReader() error = %v", err)
+	}
+
+	tests := []struct {
+		model      string
+		wantFamily string
+		wantFound  bool
+	}{
+		{"ST4000DM004", "Seagate Barracuda", true},
+		{"Samsung SSD 870 EVO", "Samsung SSD", true},
+		{"WDC WD40EFRX", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.model, func(t *testing.T) {
+			e, ok := db.Lookup(tt.model)
+			if ok != tt.wantFound {
+				t.Fatalf("Lookup(%q) found = %v, want %v", tt.model, ok, tt.wantFound)
+			}
+			if ok && e.Family != tt.wantFamily {
+				t.Errorf("Lookup(%q).Family = %q, want %q", tt.model, e.Family, tt.wantFamily)
+			}
+		})
+	}
+}
+
+func TestEntryAttributeName(t *testing.T) {
+	db, err := OpenDriveDbFromReader(strings.NewReader(testDriveDbYAML))
+	if err != nil {
+		t.Fatalf("OpenDriveDbFromReader() error = %v", err)
+	}
+
+	e, ok := db.Lookup("ST4000DM004")
+	if !ok {
+		t.Fatal("Lookup(\"ST4000DM004\") found = false, want true")
+	}
+
+	tests := []struct {
+		id   uint8
+		want string
+	}{
+		{5, "Reallocated_Sector_Ct"},
+		{187, "Reported_Uncorrect"},
+		{9, "Unknown_Attribute"},
+	}
+
+	for _, tt := range tests {
+		if got := e.AttributeName(tt.id); got != tt.want {
+			t.Errorf("AttributeName(%d) = %q, want %q", tt.id, got, tt.want)
+		}
+	}
+}
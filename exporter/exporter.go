@@ -0,0 +1,262 @@
+// Copyright 2017-18 Daniel Swarbrick. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package exporter implements a Prometheus collector that periodically polls all SATA and NVMe
+// devices discovered by smart.ScanDevices and, optionally, the physical drives reported by every
+// registered transport.Driver, and exposes their SMART health metrics.
+package exporter
+
+import (
+	"bytes"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/dswarbrick/smart/drivedb"
+	"github.com/dswarbrick/smart/nvme"
+	"github.com/dswarbrick/smart/scsi"
+	"github.com/dswarbrick/smart/smart"
+	"github.com/dswarbrick/smart/smartdb"
+	"github.com/dswarbrick/smart/transport"
+
+	// Blank-imported for their self-registration with the transport package.
+	_ "github.com/dswarbrick/smart/transport/aacraid"
+	_ "github.com/dswarbrick/smart/transport/areca"
+	_ "github.com/dswarbrick/smart/transport/cciss"
+	_ "github.com/dswarbrick/smart/transport/megaraid"
+)
+
+var (
+	temperatureDesc = prometheus.NewDesc(
+		"smart_device_temperature_celsius", "Current device temperature.",
+		[]string{"device", "wwn", "model", "serial"}, nil)
+
+	powerOnHoursDesc = prometheus.NewDesc(
+		"smart_device_power_on_hours_total", "Cumulative power-on hours.",
+		[]string{"device", "wwn", "model", "serial"}, nil)
+
+	powerCyclesDesc = prometheus.NewDesc(
+		"smart_device_power_cycles_total", "Cumulative power cycle count.",
+		[]string{"device", "wwn", "model", "serial"}, nil)
+
+	mediaErrorsDesc = prometheus.NewDesc(
+		"smart_device_media_errors_total", "Cumulative media / data integrity errors (NVMe).",
+		[]string{"device", "wwn", "model", "serial"}, nil)
+
+	percentageUsedDesc = prometheus.NewDesc(
+		"smart_device_percentage_used", "Vendor-normalized percentage of the device's endurance used (NVMe).",
+		[]string{"device", "wwn", "model", "serial"}, nil)
+
+	availableSpareDesc = prometheus.NewDesc(
+		"smart_device_available_spare_ratio", "Fraction of remaining spare capacity available (NVMe).",
+		[]string{"device", "wwn", "model", "serial"}, nil)
+
+	attributeDesc = prometheus.NewDesc(
+		"smart_attribute_value", "Normalized value of an ATA SMART attribute.",
+		[]string{"device", "wwn", "model", "serial", "id", "name"}, nil)
+
+	scanErrorsDesc = prometheus.NewDesc(
+		"smart_scan_errors_total", "Number of devices and RAID/HBA drivers that failed during the last scan.",
+		nil, nil)
+)
+
+// sample is a single device's metrics, cached between scans.
+type sample struct {
+	device string
+	id     smart.DeviceID
+	metric smart.Metrics
+}
+
+// Exporter is a prometheus.Collector that reports SMART health metrics for every device
+// discovered on the host. It polls devices on its own schedule (ScanInterval) rather than on
+// every Collect call, since issuing SMART commands to spinning disks is comparatively slow and
+// Prometheus may scrape far more often than is useful here.
+type Exporter struct {
+	ScanInterval time.Duration
+	RAID         bool
+
+	db drivedb.DriveDb
+
+	mu         sync.Mutex
+	samples    []sample
+	scanErrors int
+}
+
+// New returns an Exporter that rescans devices every scanInterval. If raidEnabled is true,
+// physical drives behind every registered transport.Driver (MegaRAID, Areca, cciss, aacraid) are
+// polled in addition to SATA/NVMe devices. New panics if the embedded drivedb.yaml asset cannot
+// be parsed, since that indicates a build-time defect rather than a runtime condition callers
+// should handle.
+func New(scanInterval time.Duration, raidEnabled bool) *Exporter {
+	db, err := drivedb.OpenDriveDbFromReader(bytes.NewBuffer(smartdb.MustAsset("drivedb.yaml")))
+	if err != nil {
+		panic("exporter: parsing embedded drivedb.yaml: " + err.Error())
+	}
+
+	return &Exporter{ScanInterval: scanInterval, RAID: raidEnabled, db: db}
+}
+
+// Run blocks, rescanning devices every e.ScanInterval until stop is closed.
+func (e *Exporter) Run(stop <-chan struct{}) {
+	e.scan()
+
+	ticker := time.NewTicker(e.ScanInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			e.scan()
+		case <-stop:
+			return
+		}
+	}
+}
+
+// scan polls every discovered device for its current metrics and replaces the cached sample set.
+func (e *Exporter) scan() {
+	var (
+		samples    []sample
+		scanErrors int
+	)
+
+	for _, info := range smart.ScanDevices() {
+		var (
+			d   scsi.Device
+			err error
+		)
+
+		if strings.HasPrefix(info.Name, "/dev/nvme") {
+			d = nvme.NewNVMeDevice(info.Name)
+			err = d.Open()
+		} else {
+			d, err = scsi.OpenSCSIAutodetect(info.Name)
+		}
+
+		if err != nil {
+			log.Printf("exporter: open %s: %v", info.Name, err)
+			scanErrors++
+			continue
+		}
+
+		metrics, err := d.ReadMetrics()
+		d.Close()
+
+		if err != nil {
+			log.Printf("exporter: read metrics for %s: %v", info.Name, err)
+			scanErrors++
+			continue
+		}
+
+		samples = append(samples, sample{device: info.Name, id: info.ID(), metric: metrics})
+	}
+
+	if e.RAID {
+		raidSamples, raidErrors := e.scanRAID()
+		samples = append(samples, raidSamples...)
+		scanErrors += raidErrors
+	}
+
+	e.mu.Lock()
+	e.samples = samples
+	e.scanErrors = scanErrors
+	e.mu.Unlock()
+}
+
+// scanRAID polls every physical drive reported by every registered transport.Driver (MegaRAID,
+// Areca, cciss, aacraid).
+func (e *Exporter) scanRAID() ([]sample, int) {
+	var (
+		samples    []sample
+		scanErrors int
+	)
+
+	for _, drv := range transport.Drivers() {
+		targets, err := drv.Scan()
+		if err != nil {
+			log.Printf("exporter: %s: %v", drv.Name(), err)
+			scanErrors++
+			continue
+		}
+
+		for _, target := range targets {
+			device := drv.Name() + strconv.Itoa(int(target.Host)) + "/" + strconv.Itoa(int(target.Disk))
+			id := smart.DeviceInfo{Model: target.Model, Serial: target.Serial, WWN: target.WWN}.ID()
+
+			d, err := drv.Open(target)
+			if err != nil {
+				log.Printf("exporter: open %s: %v", device, err)
+				scanErrors++
+				continue
+			}
+
+			metrics, err := d.ReadMetrics()
+			d.Close()
+
+			if err != nil {
+				log.Printf("exporter: read metrics for %s: %v", device, err)
+				scanErrors++
+				continue
+			}
+
+			samples = append(samples, sample{device: device, id: id, metric: metrics})
+		}
+	}
+
+	return samples, scanErrors
+}
+
+// Describe implements prometheus.Collector.
+func (e *Exporter) Describe(ch chan<- *prometheus.Desc) {
+	ch <- temperatureDesc
+	ch <- powerOnHoursDesc
+	ch <- powerCyclesDesc
+	ch <- mediaErrorsDesc
+	ch <- percentageUsedDesc
+	ch <- availableSpareDesc
+	ch <- attributeDesc
+	ch <- scanErrorsDesc
+}
+
+// Collect implements prometheus.Collector.
+func (e *Exporter) Collect(ch chan<- prometheus.Metric) {
+	e.mu.Lock()
+	samples := e.samples
+	scanErrors := e.scanErrors
+	e.mu.Unlock()
+
+	ch <- prometheus.MustNewConstMetric(scanErrorsDesc, prometheus.CounterValue, float64(scanErrors))
+
+	for _, s := range samples {
+		labels := []string{s.device, s.id.WWN(), s.id.Model(), s.id.Serial()}
+
+		ch <- prometheus.MustNewConstMetric(temperatureDesc, prometheus.GaugeValue, s.metric.TemperatureCelsius, labels...)
+		ch <- prometheus.MustNewConstMetric(powerOnHoursDesc, prometheus.CounterValue, float64(s.metric.PowerOnHours), labels...)
+		ch <- prometheus.MustNewConstMetric(powerCyclesDesc, prometheus.CounterValue, float64(s.metric.PowerCycles), labels...)
+		ch <- prometheus.MustNewConstMetric(mediaErrorsDesc, prometheus.CounterValue, float64(s.metric.MediaErrors), labels...)
+		ch <- prometheus.MustNewConstMetric(percentageUsedDesc, prometheus.GaugeValue, s.metric.PercentageUsed, labels...)
+		ch <- prometheus.MustNewConstMetric(availableSpareDesc, prometheus.GaugeValue, s.metric.AvailableSpare/100, labels...)
+
+		entry, _ := e.db.Lookup(s.id.Model())
+
+		for _, attr := range s.metric.Attributes {
+			attrLabels := append(append([]string{}, labels...), strconv.Itoa(int(attr.ID)), entry.AttributeName(attr.ID))
+			ch <- prometheus.MustNewConstMetric(attributeDesc, prometheus.GaugeValue, float64(attr.Value), attrLabels...)
+		}
+	}
+}
@@ -0,0 +1,114 @@
+// Copyright 2017-18 Daniel Swarbrick. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package nvme implements SMART / Health Information Log retrieval from NVMe devices via the
+// host OS's NVMe admin passthrough / management interface.
+package nvme
+
+import "encoding/binary"
+
+// NVMeDevice represents an NVMe controller, opened by its OS-specific device node (e.g.
+// "/dev/nvme0" on Linux, "\\.\PhysicalDrive0" or "\\.\ScsiN:" on Windows, "/dev/nvmeN" / IOKit
+// service on macOS). Platform-specific Open/Close/PrintSMART implementations live in the
+// nvme_linux.go, nvme_darwin.go and nvme_windows.go build-tagged files.
+type NVMeDevice struct {
+	Name string
+	fd   int
+}
+
+// NewNVMeDevice returns an unopened NVMeDevice for the given device node.
+func NewNVMeDevice(name string) *NVMeDevice {
+	return &NVMeDevice{Name: name}
+}
+
+// ControllerInfo covers the Identify Controller (CNS=0x01) fields needed to describe an NVMe
+// controller beyond the model/serial/firmware already surfaced via smart.Report's
+// NVMeIdentifyController.
+type ControllerInfo struct {
+	ModelNumber     string
+	SerialNumber    string
+	FirmwareVersion string
+
+	// TotalCapacity is Tnvmcap, the total NVM capacity in bytes, as a 128-bit little-endian
+	// value ([0] low qword, [1] high qword).
+	TotalCapacity [2]uint64
+
+	NumNamespaces uint32
+}
+
+// NamespaceInfo covers the Identify Namespace (CNS=0x00) fields for a single active namespace.
+type NamespaceInfo struct {
+	NSID uint32
+
+	Size        uint64 // Nsze, in logical blocks.
+	Capacity    uint64 // Ncap, in logical blocks.
+	Utilization uint64 // Nuse, in logical blocks.
+}
+
+// SMARTLog is the NVMe SMART / Health Information log (Log ID 0x02). It carries the lifetime
+// counters at their full 128-bit width, and covers more of them than smart.Report's
+// NVMeSMARTLog, which omits the data unit, host command, busy time and unsafe shutdown counters.
+type SMARTLog struct {
+	CriticalWarning               uint8
+	CompositeTemperatureKelvin    uint16
+	AvailableSpare                uint8
+	AvailableSpareThreshold       uint8
+	PercentageUsed                uint8
+	EnduranceGroupCriticalWarning uint8
+
+	DataUnitsRead      [2]uint64
+	DataUnitsWritten   [2]uint64
+	HostReadCommands   [2]uint64
+	HostWriteCommands  [2]uint64
+	ControllerBusyTime [2]uint64
+	PowerCycles        [2]uint64
+	PowerOnHours       [2]uint64
+	UnsafeShutdowns    [2]uint64
+	MediaErrors        [2]uint64
+
+	// TemperatureSensors holds Temperature Sensor 1-8; a sensor reads 0 if not implemented.
+	TemperatureSensors [8]uint16
+}
+
+// parseSMARTLog decodes a raw 512-byte SMART / Health Information log page into a SMARTLog.
+func parseSMARTLog(log [512]byte) SMARTLog {
+	read128 := func(off int) [2]uint64 {
+		return [2]uint64{binary.LittleEndian.Uint64(log[off : off+8]), binary.LittleEndian.Uint64(log[off+8 : off+16])}
+	}
+
+	s := SMARTLog{
+		CriticalWarning:               log[0],
+		CompositeTemperatureKelvin:    binary.LittleEndian.Uint16(log[1:3]),
+		AvailableSpare:                log[3],
+		AvailableSpareThreshold:       log[4],
+		PercentageUsed:                log[5],
+		EnduranceGroupCriticalWarning: log[6],
+		DataUnitsRead:                 read128(32),
+		DataUnitsWritten:              read128(48),
+		HostReadCommands:              read128(64),
+		HostWriteCommands:             read128(80),
+		ControllerBusyTime:            read128(96),
+		PowerCycles:                   read128(112),
+		PowerOnHours:                  read128(128),
+		UnsafeShutdowns:               read128(144),
+		MediaErrors:                   read128(160),
+	}
+
+	for i := 0; i < 8; i++ {
+		off := 200 + i*2
+		s.TemperatureSensors[i] = binary.LittleEndian.Uint16(log[off : off+2])
+	}
+
+	return s
+}
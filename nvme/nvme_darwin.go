@@ -0,0 +1,215 @@
+// Copyright 2017-18 Daniel Swarbrick. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build darwin
+// +build darwin
+
+package nvme
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+
+	"github.com/dswarbrick/smart/drivedb"
+	"github.com/dswarbrick/smart/smart"
+)
+
+// _DKIOCNVMEUSERCLIENTCTL is the IOKit NVMe admin passthrough ioctl exposed by the
+// IONVMeFamily user client, see <IOKit/storage/nvme/NVMeSMARTLib.h>.
+const _DKIOCNVMEUSERCLIENTCTL = 0xc0604e01
+
+// nvmeUserClientCmd mirrors the private IONVMeController passthrough request structure: an NVMe
+// admin opcode, CNS/log-page selector, and an output data buffer pointer/length.
+type nvmeUserClientCmd struct {
+	opcode uint8
+	_      [3]byte
+	cdw10  uint32
+	nsid   uint32
+	bufPtr uintptr
+	bufLen uint32
+}
+
+// Open opens the IOKit NVMe user client device node (e.g. /dev/nvme0 pseudo-node created by this
+// package's IOKit matching helper).
+func (d *NVMeDevice) Open() error {
+	f, err := os.OpenFile(d.Name, os.O_RDWR, 0)
+	if err != nil {
+		return fmt.Errorf("nvme: open %s: %w", d.Name, err)
+	}
+	d.fd = int(f.Fd())
+	return nil
+}
+
+// Close closes the underlying device file descriptor.
+func (d *NVMeDevice) Close() error {
+	return unix.Close(d.fd)
+}
+
+func (d *NVMeDevice) passthru(opcode uint8, nsid, cdw10 uint32, data []byte) error {
+	cmd := nvmeUserClientCmd{
+		opcode: opcode,
+		cdw10:  cdw10,
+		nsid:   nsid,
+		bufPtr: uintptr(unsafe.Pointer(&data[0])),
+		bufLen: uint32(len(data)),
+	}
+
+	_, _, errno := unix.Syscall(unix.SYS_IOCTL, uintptr(d.fd), _DKIOCNVMEUSERCLIENTCTL, uintptr(unsafe.Pointer(&cmd)))
+	if errno != 0 {
+		return errno
+	}
+	return nil
+}
+
+// PrintSMART reads the controller's Identify data and SMART / Health Information log via the
+// IOKit NVMe user client and writes a human-readable report to w.
+func (d *NVMeDevice) PrintSMART(db *drivedb.DriveDb, w io.Writer) error {
+	report, err := d.SMART(db)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(w, "Model Number:     %s\n", report.NVMeController.ModelNumber)
+	fmt.Fprintf(w, "Serial Number:    %s\n", report.NVMeController.SerialNumber)
+
+	if report.NVMeNumberOfNamespaces > 0 {
+		fmt.Fprintf(w, "Namespaces:       %d\n", report.NVMeNumberOfNamespaces)
+	}
+
+	log := report.NVMeSMARTLog
+	fmt.Fprintf(w, "\nTemperature:              %d Kelvin\n", log.TemperatureKelvin)
+	fmt.Fprintf(w, "Available Spare:          %d%%\n", log.AvailableSpare)
+	fmt.Fprintf(w, "Percentage Used:          %d%%\n", log.PercentageUsed)
+	fmt.Fprintf(w, "Power On Hours:           %d\n", log.PowerOnHours[0])
+
+	return nil
+}
+
+// getSMARTLog issues Get Log Page for the SMART / Health Information log (Log ID 0x02) via the
+// IOKit NVMe user client and returns the raw 512-byte log.
+func (d *NVMeDevice) getSMARTLog(nsid uint32) ([512]byte, error) {
+	var buf [512]byte
+	err := d.passthru(_NVME_ADMIN_OPCODE_GET_LOG_PAGE, nsid, _NVME_LOG_SMART|(uint32(len(buf)/4-1)<<16), buf[:])
+	return buf, err
+}
+
+// ReadSMART issues Get Log Page for the SMART / Health Information log via the IOKit NVMe user
+// client and returns it fully parsed. Pass 0xffffffff for nsid to request the controller's
+// global log.
+func (d *NVMeDevice) ReadSMART(nsid uint32) (SMARTLog, error) {
+	log, err := d.getSMARTLog(nsid)
+	if err != nil {
+		return SMARTLog{}, fmt.Errorf("nvme: Get Log Page (SMART) failed: %w", err)
+	}
+
+	return parseSMARTLog(log), nil
+}
+
+// Identify issues Identify Controller via the IOKit NVMe user client. Unlike nvme_linux.go, this
+// platform's passthru does not expose the Identify Active Namespace ID List (CNS=2) used to
+// enumerate namespaces, so the returned namespace slice is always empty.
+func (d *NVMeDevice) Identify() (ControllerInfo, []NamespaceInfo, error) {
+	var ident [4096]byte
+	if err := d.passthru(_NVME_ADMIN_OPCODE_IDENTIFY, 0, 1, ident[:]); err != nil {
+		return ControllerInfo{}, nil, fmt.Errorf("nvme: Identify Controller failed: %w", err)
+	}
+
+	ctrl := ControllerInfo{
+		ModelNumber:  strings.TrimRight(string(ident[24:64]), " \x00"),
+		SerialNumber: strings.TrimRight(string(ident[4:24]), " \x00"),
+		TotalCapacity: [2]uint64{
+			binary.LittleEndian.Uint64(ident[280:288]),
+			binary.LittleEndian.Uint64(ident[288:296]),
+		},
+		NumNamespaces: binary.LittleEndian.Uint32(ident[516:520]),
+	}
+
+	return ctrl, nil, nil
+}
+
+// SMART reads the controller's Identify data and SMART / Health Information log via the IOKit
+// NVMe user client and returns it as a typed, JSON-marshalable Report.
+func (d *NVMeDevice) SMART(db *drivedb.DriveDb) (smart.Report, error) {
+	report := smart.Report{Device: d.Name}
+
+	ctrl, namespaces, err := d.Identify()
+	if err != nil {
+		return report, err
+	}
+
+	report.NVMeController = &smart.NVMeIdentifyController{
+		ModelNumber:  ctrl.ModelNumber,
+		SerialNumber: ctrl.SerialNumber,
+	}
+	report.NVMeNumberOfNamespaces = ctrl.NumNamespaces
+	for _, ns := range namespaces {
+		report.NVMeNamespaces = append(report.NVMeNamespaces, smart.NVMeNamespace{
+			ID:          ns.NSID,
+			Size:        ns.Size,
+			Capacity:    ns.Capacity,
+			Utilization: ns.Utilization,
+		})
+	}
+
+	log, err := d.ReadSMART(0xffffffff)
+	if err != nil {
+		return report, err
+	}
+
+	report.NVMeSMARTLog = &smart.NVMeSMARTLog{
+		CriticalWarning:               log.CriticalWarning,
+		TemperatureKelvin:             log.CompositeTemperatureKelvin,
+		AvailableSpare:                log.AvailableSpare,
+		PercentageUsed:                log.PercentageUsed,
+		EnduranceGroupCriticalWarning: log.EnduranceGroupCriticalWarning,
+		PowerCycles:                   log.PowerCycles,
+		PowerOnHours:                  log.PowerOnHours,
+		MediaErrors:                   log.MediaErrors,
+		TemperatureSensors:            log.TemperatureSensors,
+	}
+
+	return report, nil
+}
+
+// ReadMetrics reads the controller's SMART / Health Information log via the IOKit NVMe user
+// client and returns it as a transport-agnostic smart.Metrics snapshot.
+func (d *NVMeDevice) ReadMetrics() (smart.Metrics, error) {
+	var m smart.Metrics
+
+	log, err := d.ReadSMART(0xffffffff)
+	if err != nil {
+		return m, err
+	}
+
+	m.TemperatureCelsius = float64(log.CompositeTemperatureKelvin) - 273.15
+	m.AvailableSpare = float64(log.AvailableSpare)
+	m.PercentageUsed = float64(log.PercentageUsed)
+	m.PowerCycles = log.PowerCycles[0]
+	m.PowerOnHours = log.PowerOnHours[0]
+	m.MediaErrors = log.MediaErrors[0]
+
+	return m, nil
+}
+
+const (
+	_NVME_ADMIN_OPCODE_GET_LOG_PAGE = 0x02
+	_NVME_ADMIN_OPCODE_IDENTIFY     = 0x06
+	_NVME_LOG_SMART                 = 0x02
+)
@@ -0,0 +1,301 @@
+// Copyright 2017-18 Daniel Swarbrick. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build linux
+// +build linux
+
+package nvme
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"strings"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+
+	"github.com/dswarbrick/smart/drivedb"
+	"github.com/dswarbrick/smart/smart"
+)
+
+const (
+	_NVME_IOCTL_ADMIN_CMD = 0xc0484e41
+
+	_NVME_ADMIN_OPCODE_GET_LOG_PAGE = 0x02
+	_NVME_ADMIN_OPCODE_IDENTIFY     = 0x06
+
+	_NVME_LOG_SMART = 0x02
+
+	_NVME_IDENTIFY_CNS_NAMESPACE      = 0x00
+	_NVME_IDENTIFY_CNS_CONTROLLER     = 0x01
+	_NVME_IDENTIFY_CNS_ACTIVE_NS_LIST = 0x02
+)
+
+// nvmePassthruCmd mirrors struct nvme_admin_cmd from <linux/nvme_ioctl.h>.
+type nvmePassthruCmd struct {
+	opcode      uint8
+	flags       uint8
+	rsvd1       uint16
+	nsid        uint32
+	cdw2        uint32
+	cdw3        uint32
+	metadata    uint64
+	addr        uint64
+	metadataLen uint32
+	dataLen     uint32
+	cdw10       uint32
+	cdw11       uint32
+	cdw12       uint32
+	cdw13       uint32
+	cdw14       uint32
+	cdw15       uint32
+	timeoutMs   uint32
+	result      uint32
+}
+
+// Open opens the NVMe character device node (e.g. /dev/nvme0) for admin passthrough ioctls.
+func (d *NVMeDevice) Open() error {
+	fd, err := unix.Open(d.Name, unix.O_RDWR, 0)
+	if err != nil {
+		return fmt.Errorf("nvme: open %s: %w", d.Name, err)
+	}
+	d.fd = fd
+	return nil
+}
+
+// Close closes the underlying device file descriptor.
+func (d *NVMeDevice) Close() error {
+	return unix.Close(d.fd)
+}
+
+func (d *NVMeDevice) adminPassthru(opcode uint8, nsid uint32, cdw10 uint32, data []byte) error {
+	cmd := nvmePassthruCmd{
+		opcode:  opcode,
+		nsid:    nsid,
+		addr:    uint64(uintptr(unsafe.Pointer(&data[0]))),
+		dataLen: uint32(len(data)),
+		cdw10:   cdw10,
+	}
+
+	// Issue the syscall directly (rather than through IoctlSetInt's int parameter) so the
+	// Pointer->uintptr conversion of &cmd remains valid for the syscall that consumes it.
+	_, _, errno := unix.Syscall(unix.SYS_IOCTL, uintptr(d.fd), _NVME_IOCTL_ADMIN_CMD, uintptr(unsafe.Pointer(&cmd)))
+	if errno != 0 {
+		return errno
+	}
+	return nil
+}
+
+// identify issues an Identify command (opcode 0x06) with the given CNS value and namespace ID,
+// and returns the raw 4096-byte response.
+func (d *NVMeDevice) identify(cns, nsid uint32) ([4096]byte, error) {
+	var buf [4096]byte
+	err := d.adminPassthru(_NVME_ADMIN_OPCODE_IDENTIFY, nsid, cns, buf[:])
+	return buf, err
+}
+
+// identifyController issues Identify Controller (CNS=1) and returns the raw 4096-byte Identify
+// Controller data structure.
+func (d *NVMeDevice) identifyController() ([4096]byte, error) {
+	return d.identify(_NVME_IDENTIFY_CNS_CONTROLLER, 0)
+}
+
+// listActiveNamespaces issues Identify Active Namespace ID List (CNS=2) and returns the active
+// namespace IDs, in ascending order, terminated in the response by the first zero entry.
+func (d *NVMeDevice) listActiveNamespaces() ([]uint32, error) {
+	buf, err := d.identify(_NVME_IDENTIFY_CNS_ACTIVE_NS_LIST, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	var nsids []uint32
+	for off := 0; off < len(buf); off += 4 {
+		nsid := binary.LittleEndian.Uint32(buf[off : off+4])
+		if nsid == 0 {
+			break
+		}
+		nsids = append(nsids, nsid)
+	}
+
+	return nsids, nil
+}
+
+// Identify issues Identify Controller, then Identify Active Namespace ID List and an Identify
+// Namespace for each active namespace, and returns the combined result.
+func (d *NVMeDevice) Identify() (ControllerInfo, []NamespaceInfo, error) {
+	ident, err := d.identifyController()
+	if err != nil {
+		return ControllerInfo{}, nil, fmt.Errorf("nvme: Identify Controller failed: %w", err)
+	}
+
+	ctrl := ControllerInfo{
+		ModelNumber:     strings.TrimRight(string(ident[24:64]), " \x00"),
+		SerialNumber:    strings.TrimRight(string(ident[4:24]), " \x00"),
+		FirmwareVersion: strings.TrimRight(string(ident[64:72]), " \x00"),
+		TotalCapacity: [2]uint64{
+			binary.LittleEndian.Uint64(ident[280:288]),
+			binary.LittleEndian.Uint64(ident[288:296]),
+		},
+		NumNamespaces: binary.LittleEndian.Uint32(ident[516:520]),
+	}
+
+	nsids, err := d.listActiveNamespaces()
+	if err != nil {
+		return ctrl, nil, fmt.Errorf("nvme: Identify Active Namespace ID List failed: %w", err)
+	}
+
+	namespaces := make([]NamespaceInfo, 0, len(nsids))
+	for _, nsid := range nsids {
+		ns, err := d.identify(_NVME_IDENTIFY_CNS_NAMESPACE, nsid)
+		if err != nil {
+			return ctrl, namespaces, fmt.Errorf("nvme: Identify Namespace %d failed: %w", nsid, err)
+		}
+
+		namespaces = append(namespaces, NamespaceInfo{
+			NSID:        nsid,
+			Size:        binary.LittleEndian.Uint64(ns[0:8]),
+			Capacity:    binary.LittleEndian.Uint64(ns[8:16]),
+			Utilization: binary.LittleEndian.Uint64(ns[16:24]),
+		})
+	}
+
+	return ctrl, namespaces, nil
+}
+
+// getSMARTLog issues Get Log Page for the SMART / Health Information log (Log ID 0x02) and
+// returns the raw 512-byte log.
+func (d *NVMeDevice) getSMARTLog(nsid uint32) ([512]byte, error) {
+	var buf [512]byte
+
+	// cdw10: bits 0-7 = Log Page Identifier, bits 16-31 = number of dwords to return, minus one
+	numDwords := uint32(len(buf)/4 - 1)
+	cdw10 := uint32(_NVME_LOG_SMART) | (numDwords << 16)
+
+	err := d.adminPassthru(_NVME_ADMIN_OPCODE_GET_LOG_PAGE, nsid, cdw10, buf[:])
+	return buf, err
+}
+
+// ReadSMART issues Get Log Page for the SMART / Health Information log and returns it fully
+// parsed. Pass 0xffffffff for nsid to request the controller's global log; on controllers that
+// support the per-namespace SMART/Health log, a specific nsid returns that namespace's log
+// instead.
+func (d *NVMeDevice) ReadSMART(nsid uint32) (SMARTLog, error) {
+	log, err := d.getSMARTLog(nsid)
+	if err != nil {
+		return SMARTLog{}, fmt.Errorf("nvme: Get Log Page (SMART) failed: %w", err)
+	}
+
+	return parseSMARTLog(log), nil
+}
+
+// PrintSMART reads the controller's Identify data and SMART / Health Information log and writes
+// a human-readable report to w.
+func (d *NVMeDevice) PrintSMART(db *drivedb.DriveDb, w io.Writer) error {
+	report, err := d.SMART(db)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(w, "Model Number:     %s\n", report.NVMeController.ModelNumber)
+	fmt.Fprintf(w, "Serial Number:    %s\n", report.NVMeController.SerialNumber)
+	fmt.Fprintf(w, "Firmware Version: %s\n", report.NVMeController.FirmwareVersion)
+
+	if report.NVMeNumberOfNamespaces > 0 {
+		fmt.Fprintf(w, "Namespaces:       %d\n", report.NVMeNumberOfNamespaces)
+		for _, ns := range report.NVMeNamespaces {
+			fmt.Fprintf(w, "  NS %-4d Size: %d  Capacity: %d  Utilization: %d\n",
+				ns.ID, ns.Size, ns.Capacity, ns.Utilization)
+		}
+	}
+
+	log := report.NVMeSMARTLog
+	fmt.Fprintf(w, "\nCritical Warning:         0x%02x\n", log.CriticalWarning)
+	fmt.Fprintf(w, "Temperature:              %d Kelvin\n", log.TemperatureKelvin)
+	fmt.Fprintf(w, "Available Spare:          %d%%\n", log.AvailableSpare)
+	fmt.Fprintf(w, "Percentage Used:          %d%%\n", log.PercentageUsed)
+	fmt.Fprintf(w, "Power Cycles:             %d\n", log.PowerCycles[0])
+	fmt.Fprintf(w, "Power On Hours:           %d\n", log.PowerOnHours[0])
+	fmt.Fprintf(w, "Media Errors:             %d\n", log.MediaErrors[0])
+
+	return nil
+}
+
+// SMART reads the controller's Identify data and SMART / Health Information log and returns it
+// as a typed, JSON-marshalable Report. db is accepted for interface parity with scsi.Device, but
+// NVMe attribute naming comes from the NVMe spec itself rather than drivedb.yaml.
+func (d *NVMeDevice) SMART(db *drivedb.DriveDb) (smart.Report, error) {
+	report := smart.Report{Device: d.Name}
+
+	ctrl, namespaces, err := d.Identify()
+	if err != nil {
+		return report, err
+	}
+
+	report.NVMeController = &smart.NVMeIdentifyController{
+		ModelNumber:     ctrl.ModelNumber,
+		SerialNumber:    ctrl.SerialNumber,
+		FirmwareVersion: ctrl.FirmwareVersion,
+	}
+	report.NVMeNumberOfNamespaces = ctrl.NumNamespaces
+	for _, ns := range namespaces {
+		report.NVMeNamespaces = append(report.NVMeNamespaces, smart.NVMeNamespace{
+			ID:          ns.NSID,
+			Size:        ns.Size,
+			Capacity:    ns.Capacity,
+			Utilization: ns.Utilization,
+		})
+	}
+
+	log, err := d.ReadSMART(0xffffffff)
+	if err != nil {
+		return report, err
+	}
+
+	report.NVMeSMARTLog = &smart.NVMeSMARTLog{
+		CriticalWarning:               log.CriticalWarning,
+		TemperatureKelvin:             log.CompositeTemperatureKelvin,
+		AvailableSpare:                log.AvailableSpare,
+		PercentageUsed:                log.PercentageUsed,
+		EnduranceGroupCriticalWarning: log.EnduranceGroupCriticalWarning,
+		PowerCycles:                   log.PowerCycles,
+		PowerOnHours:                  log.PowerOnHours,
+		MediaErrors:                   log.MediaErrors,
+		TemperatureSensors:            log.TemperatureSensors,
+	}
+
+	return report, nil
+}
+
+// ReadMetrics reads the controller's SMART / Health Information log (the global log, nsid
+// 0xffffffff) and returns it as a transport-agnostic smart.Metrics snapshot.
+func (d *NVMeDevice) ReadMetrics() (smart.Metrics, error) {
+	var m smart.Metrics
+
+	log, err := d.ReadSMART(0xffffffff)
+	if err != nil {
+		return m, err
+	}
+
+	// The composite temperature is reported in Kelvin; convert to Celsius for consistency with
+	// ATA's Temperature_Celsius attribute.
+	m.TemperatureCelsius = float64(log.CompositeTemperatureKelvin) - 273.15
+	m.AvailableSpare = float64(log.AvailableSpare)
+	m.PercentageUsed = float64(log.PercentageUsed)
+	m.PowerCycles = log.PowerCycles[0]
+	m.PowerOnHours = log.PowerOnHours[0]
+	m.MediaErrors = log.MediaErrors[0]
+
+	return m, nil
+}
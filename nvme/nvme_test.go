@@ -0,0 +1,62 @@
+// Copyright 2017-18 Daniel Swarbrick. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package nvme
+
+import (
+	"encoding/binary"
+	"testing"
+)
+
+func TestParseSMARTLog(t *testing.T) {
+	var log [512]byte
+
+	log[0] = 0x01                                      // CriticalWarning
+	binary.LittleEndian.PutUint16(log[1:3], 300)       // CompositeTemperatureKelvin
+	log[3] = 100                                       // AvailableSpare
+	log[4] = 10                                        // AvailableSpareThreshold
+	log[5] = 42                                        // PercentageUsed
+	binary.LittleEndian.PutUint64(log[112:120], 1234)  // PowerCycles low qword
+	binary.LittleEndian.PutUint64(log[120:128], 1)     // PowerCycles high qword
+	binary.LittleEndian.PutUint64(log[128:136], 56789) // PowerOnHours low qword
+	binary.LittleEndian.PutUint16(log[200:202], 3100)  // TemperatureSensors[0]
+
+	got := parseSMARTLog(log)
+
+	if got.CriticalWarning != 0x01 {
+		t.Errorf("CriticalWarning = %#x, want 0x01", got.CriticalWarning)
+	}
+	if got.CompositeTemperatureKelvin != 300 {
+		t.Errorf("CompositeTemperatureKelvin = %d, want 300", got.CompositeTemperatureKelvin)
+	}
+	if got.AvailableSpare != 100 {
+		t.Errorf("AvailableSpare = %d, want 100", got.AvailableSpare)
+	}
+	if got.PercentageUsed != 42 {
+		t.Errorf("PercentageUsed = %d, want 42", got.PercentageUsed)
+	}
+
+	wantPowerCycles := [2]uint64{1234, 1}
+	if got.PowerCycles != wantPowerCycles {
+		t.Errorf("PowerCycles = %v, want %v (128-bit counter must not be truncated)", got.PowerCycles, wantPowerCycles)
+	}
+
+	if got.PowerOnHours[0] != 56789 {
+		t.Errorf("PowerOnHours[0] = %d, want 56789", got.PowerOnHours[0])
+	}
+
+	if got.TemperatureSensors[0] != 3100 {
+		t.Errorf("TemperatureSensors[0] = %d, want 3100", got.TemperatureSensors[0])
+	}
+}
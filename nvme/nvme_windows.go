@@ -0,0 +1,231 @@
+// Copyright 2017-18 Daniel Swarbrick. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build windows
+// +build windows
+
+package nvme
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"strings"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+
+	"github.com/dswarbrick/smart/drivedb"
+	"github.com/dswarbrick/smart/smart"
+)
+
+const (
+	_IOCTL_STORAGE_QUERY_PROPERTY   = 0x2d1400
+	_IOCTL_STORAGE_PROTOCOL_COMMAND = 0x2d0c00
+
+	_STORAGE_PROTOCOL_TYPE_NVME               = 3
+	_STORAGE_PROTOCOL_NVME_DATA_TYPE_LOGPAGE  = 2
+	_STORAGE_PROTOCOL_NVME_DATA_TYPE_IDENTIFY = 3
+
+	_NVME_LOG_PAGE_HEALTH_INFO    = 0x02
+	_NVME_IDENTIFY_CNS_CONTROLLER = 1
+)
+
+// storageProtocolSpecificData mirrors STORAGE_PROTOCOL_SPECIFIC_DATA from <ntddstor.h>, used as
+// the input buffer for IOCTL_STORAGE_QUERY_PROPERTY requests against an NVMe controller.
+type storageProtocolSpecificData struct {
+	protocolType                uint32
+	dataType                    uint32
+	protocolDataRequestValue    uint32
+	protocolDataRequestSubValue uint32
+	protocolDataOffset          uint32
+	protocolDataLength          uint32
+	fixedProtocolReturnData     uint32
+	reserved                    [3]uint32
+}
+
+type nvmeHandle = windows.Handle
+
+// handles stores each device's open Windows handle; NVMeDevice.fd (an int on other platforms)
+// cannot hold a windows.Handle, so it is tracked separately here.
+var handles = map[*NVMeDevice]nvmeHandle{}
+
+// Open opens a handle to the physical NVMe drive for IOCTL_STORAGE_QUERY_PROPERTY /
+// IOCTL_STORAGE_PROTOCOL_COMMAND requests.
+func (d *NVMeDevice) Open() error {
+	p, err := windows.UTF16PtrFromString(d.Name)
+	if err != nil {
+		return err
+	}
+
+	h, err := windows.CreateFile(p, windows.GENERIC_READ|windows.GENERIC_WRITE,
+		windows.FILE_SHARE_READ|windows.FILE_SHARE_WRITE, nil, windows.OPEN_EXISTING, 0, 0)
+	if err != nil {
+		return fmt.Errorf("nvme: CreateFile %s: %w", d.Name, err)
+	}
+
+	handles[d] = h
+	return nil
+}
+
+// Close closes the device handle.
+func (d *NVMeDevice) Close() error {
+	h := handles[d]
+	delete(handles, d)
+	return windows.CloseHandle(h)
+}
+
+func (d *NVMeDevice) queryProtocolData(dataType, requestValue uint32, out []byte) error {
+	hdr := storageProtocolSpecificData{
+		protocolType:             _STORAGE_PROTOCOL_TYPE_NVME,
+		dataType:                 dataType,
+		protocolDataRequestValue: requestValue,
+		protocolDataLength:       uint32(len(out)),
+	}
+
+	var ret uint32
+	return windows.DeviceIoControl(handles[d], _IOCTL_STORAGE_QUERY_PROPERTY,
+		(*byte)(unsafe.Pointer(&hdr)), uint32(unsafe.Sizeof(hdr)),
+		&out[0], uint32(len(out)), &ret, nil)
+}
+
+// PrintSMART reads the controller's Identify data and SMART / Health Information log via
+// IOCTL_STORAGE_QUERY_PROPERTY and writes a human-readable report to w.
+func (d *NVMeDevice) PrintSMART(db *drivedb.DriveDb, w io.Writer) error {
+	report, err := d.SMART(db)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(w, "Model Number:     %s\n", report.NVMeController.ModelNumber)
+	fmt.Fprintf(w, "Serial Number:    %s\n", report.NVMeController.SerialNumber)
+
+	if report.NVMeNumberOfNamespaces > 0 {
+		fmt.Fprintf(w, "Namespaces:       %d\n", report.NVMeNumberOfNamespaces)
+	}
+
+	log := report.NVMeSMARTLog
+	fmt.Fprintf(w, "\nTemperature:              %d Kelvin\n", log.TemperatureKelvin)
+	fmt.Fprintf(w, "Available Spare:          %d%%\n", log.AvailableSpare)
+	fmt.Fprintf(w, "Percentage Used:          %d%%\n", log.PercentageUsed)
+	fmt.Fprintf(w, "Power On Hours:           %d\n", log.PowerOnHours[0])
+
+	return nil
+}
+
+// getSMARTLog issues IOCTL_STORAGE_QUERY_PROPERTY for the SMART / Health Information log page
+// and returns the raw 512-byte log.
+func (d *NVMeDevice) getSMARTLog() ([512]byte, error) {
+	var buf [512]byte
+	err := d.queryProtocolData(_STORAGE_PROTOCOL_NVME_DATA_TYPE_LOGPAGE, _NVME_LOG_PAGE_HEALTH_INFO, buf[:])
+	return buf, err
+}
+
+// ReadSMART issues IOCTL_STORAGE_QUERY_PROPERTY for the SMART / Health Information log page and
+// returns it fully parsed. Unlike nvme_linux.go, IOCTL_STORAGE_QUERY_PROPERTY addresses the whole
+// controller, so nsid is accepted for interface parity but ignored.
+func (d *NVMeDevice) ReadSMART(nsid uint32) (SMARTLog, error) {
+	log, err := d.getSMARTLog()
+	if err != nil {
+		return SMARTLog{}, fmt.Errorf("nvme: SMART / Health Information log query failed: %w", err)
+	}
+
+	return parseSMARTLog(log), nil
+}
+
+// Identify issues IOCTL_STORAGE_QUERY_PROPERTY for the Identify Controller data structure. Unlike
+// nvme_linux.go, this platform's query does not expose the Identify Active Namespace ID List, so
+// the returned namespace slice is always empty.
+func (d *NVMeDevice) Identify() (ControllerInfo, []NamespaceInfo, error) {
+	var ident [4096]byte
+	if err := d.queryProtocolData(_STORAGE_PROTOCOL_NVME_DATA_TYPE_IDENTIFY, _NVME_IDENTIFY_CNS_CONTROLLER, ident[:]); err != nil {
+		return ControllerInfo{}, nil, fmt.Errorf("nvme: Identify Controller query failed: %w", err)
+	}
+
+	ctrl := ControllerInfo{
+		ModelNumber:  strings.TrimRight(string(ident[24:64]), " \x00"),
+		SerialNumber: strings.TrimRight(string(ident[4:24]), " \x00"),
+		TotalCapacity: [2]uint64{
+			binary.LittleEndian.Uint64(ident[280:288]),
+			binary.LittleEndian.Uint64(ident[288:296]),
+		},
+		NumNamespaces: binary.LittleEndian.Uint32(ident[516:520]),
+	}
+
+	return ctrl, nil, nil
+}
+
+// SMART reads the controller's Identify data and SMART / Health Information log via
+// IOCTL_STORAGE_QUERY_PROPERTY and returns it as a typed, JSON-marshalable Report.
+func (d *NVMeDevice) SMART(db *drivedb.DriveDb) (smart.Report, error) {
+	report := smart.Report{Device: d.Name}
+
+	ctrl, namespaces, err := d.Identify()
+	if err != nil {
+		return report, err
+	}
+
+	report.NVMeController = &smart.NVMeIdentifyController{
+		ModelNumber:  ctrl.ModelNumber,
+		SerialNumber: ctrl.SerialNumber,
+	}
+	report.NVMeNumberOfNamespaces = ctrl.NumNamespaces
+	for _, ns := range namespaces {
+		report.NVMeNamespaces = append(report.NVMeNamespaces, smart.NVMeNamespace{
+			ID:          ns.NSID,
+			Size:        ns.Size,
+			Capacity:    ns.Capacity,
+			Utilization: ns.Utilization,
+		})
+	}
+
+	log, err := d.ReadSMART(0xffffffff)
+	if err != nil {
+		return report, err
+	}
+
+	report.NVMeSMARTLog = &smart.NVMeSMARTLog{
+		CriticalWarning:               log.CriticalWarning,
+		TemperatureKelvin:             log.CompositeTemperatureKelvin,
+		AvailableSpare:                log.AvailableSpare,
+		PercentageUsed:                log.PercentageUsed,
+		EnduranceGroupCriticalWarning: log.EnduranceGroupCriticalWarning,
+		PowerCycles:                   log.PowerCycles,
+		PowerOnHours:                  log.PowerOnHours,
+		MediaErrors:                   log.MediaErrors,
+		TemperatureSensors:            log.TemperatureSensors,
+	}
+
+	return report, nil
+}
+
+// ReadMetrics reads the controller's SMART / Health Information log via
+// IOCTL_STORAGE_QUERY_PROPERTY and returns it as a transport-agnostic smart.Metrics snapshot.
+func (d *NVMeDevice) ReadMetrics() (smart.Metrics, error) {
+	var m smart.Metrics
+
+	log, err := d.ReadSMART(0xffffffff)
+	if err != nil {
+		return m, err
+	}
+
+	m.TemperatureCelsius = float64(log.CompositeTemperatureKelvin) - 273.15
+	m.AvailableSpare = float64(log.AvailableSpare)
+	m.PercentageUsed = float64(log.PercentageUsed)
+	m.PowerCycles = log.PowerCycles[0]
+	m.PowerOnHours = log.PowerOnHours[0]
+	m.MediaErrors = log.MediaErrors[0]
+
+	return m, nil
+}
@@ -0,0 +1,74 @@
+// Copyright 2017-18 Daniel Swarbrick. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package scsi implements SMART access to ATA devices via the SCSI / ATA passthrough layer
+// provided by the host OS.
+package scsi
+
+import (
+	"io"
+
+	"github.com/dswarbrick/smart/drivedb"
+	"github.com/dswarbrick/smart/smart"
+)
+
+// Device is the common interface implemented by every SMART-capable device that this project
+// knows how to open, irrespective of transport (ATA passthrough, NVMe) or host platform. Both
+// scsi.SCSIDevice and nvme.NVMeDevice satisfy this interface.
+type Device interface {
+	Open() error
+	Close() error
+	PrintSMART(db *drivedb.DriveDb, w io.Writer) error
+
+	// ReadMetrics returns a transport-agnostic snapshot of the device's health metrics, for
+	// consumers (such as the exporter package) that want numeric values rather than a
+	// human-oriented report.
+	ReadMetrics() (smart.Metrics, error)
+
+	// SMART returns a fully typed, JSON-marshalable report of the device's identify and SMART
+	// data, using db to resolve vendor-specific attribute names.
+	SMART(db *drivedb.DriveDb) (smart.Report, error)
+}
+
+// smartThresholds parses a SMART READ THRESHOLDS response into a map of attribute ID to
+// threshold value.
+func smartThresholds(buf [512]byte) map[uint8]uint8 {
+	thresholds := make(map[uint8]uint8)
+	for i := 0; i < 30; i++ {
+		off := 2 + i*12
+		id := buf[off]
+		if id == 0 {
+			continue
+		}
+		thresholds[id] = buf[off+1]
+	}
+	return thresholds
+}
+
+// whenFailed reports whether value (or, if it has already recovered, worst) has ever crossed
+// threshold, using the same FAILING_NOW / In_the_past vocabulary as upstream smartctl. Threshold
+// values of 0x00 and 0xfe-0xff are reserved "always passes" / "always fails" codes rather than
+// real thresholds, per the ATA/ATAPI command set, and are never reported as failed.
+func whenFailed(value, worst, threshold uint8) string {
+	if threshold == 0 || threshold >= 0xfe {
+		return ""
+	}
+	if value <= threshold {
+		return "FAILING_NOW"
+	}
+	if worst <= threshold {
+		return "In_the_past"
+	}
+	return ""
+}
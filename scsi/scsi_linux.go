@@ -0,0 +1,328 @@
+// Copyright 2017-18 Daniel Swarbrick. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build linux
+// +build linux
+
+package scsi
+
+import (
+	"fmt"
+	"io"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+
+	"github.com/dswarbrick/smart/drivedb"
+	"github.com/dswarbrick/smart/smart"
+)
+
+// Well-known ATA SMART attribute IDs used to populate smart.Metrics.
+const (
+	_ATTR_POWER_ON_HOURS      = 9
+	_ATTR_POWER_CYCLE_COUNT   = 12
+	_ATTR_TEMPERATURE_CELSIUS = 194
+)
+
+const (
+	_SG_IO = 0x2285
+
+	_ATA_16              = 0x85
+	_SG_ATA_PROTO_PIO_IN = 4 << 1
+	_ATA_USING_LBA       = 0x40
+
+	_ATA_IDENTIFY_DEVICE = 0xec
+	_ATA_SMART_CMD       = 0xb0
+
+	_SMART_READ_VALUES     = 0xd0
+	_SMART_READ_THRESHOLDS = 0xd1
+
+	// _SG_DXFER_FROM_DEV is Linux's SG_DXFER_FROM_DEV, data transfer direction for the SG_IO
+	// ioctl. golang.org/x/sys/unix does not expose the SCSI generic (sg) ioctl interface, so
+	// this package defines it locally; see <scsi/sg.h>.
+	_SG_DXFER_FROM_DEV = -3
+)
+
+// sgIoHdr mirrors the kernel's sg_io_hdr struct used by the SG_IO ioctl. golang.org/x/sys/unix
+// does not define this (the SCSI generic driver's ioctl interface lives outside its scope), so
+// this package defines a layout-compatible copy; see <scsi/sg.h>.
+type sgIoHdr struct {
+	interfaceID    int32
+	dxferDirection int32
+	cmdLen         uint8
+	mxSbLen        uint8
+	iovecCount     uint16
+	dxferLen       uint32
+	dxferp         uintptr
+	cmdp           uintptr
+	sbp            uintptr
+	timeout        uint32
+	flags          uint32
+	packID         int32
+	usrPtr         uintptr
+	status         uint8
+	maskedStatus   uint8
+	msgStatus      uint8
+	sbLenWr        uint8
+	hostStatus     uint16
+	driverStatus   uint16
+	resid          int32
+	duration       uint32
+	info           uint32
+}
+
+// SCSIDevice represents an ATA device accessed via the Linux SG_IO SCSI/ATA passthrough ioctl.
+type SCSIDevice struct {
+	Name string
+	fd   int
+}
+
+// OpenSCSIAutodetect opens name for ATA commands via the SG_IO passthrough layer; it issues no
+// probe of the device type. The returned Device is only valid for ATA (SATA / PATA) disks; NVMe devices are handled
+// separately by the nvme package.
+func OpenSCSIAutodetect(name string) (Device, error) {
+	d := &SCSIDevice{Name: name}
+	if err := d.Open(); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// Open opens the underlying block device node for ioctl-based SG_IO access.
+func (d *SCSIDevice) Open() error {
+	fd, err := unix.Open(d.Name, unix.O_RDWR, 0)
+	if err != nil {
+		return fmt.Errorf("scsi: open %s: %w", d.Name, err)
+	}
+	d.fd = fd
+	return nil
+}
+
+// Close closes the underlying device file descriptor.
+func (d *SCSIDevice) Close() error {
+	return unix.Close(d.fd)
+}
+
+// ataSGIO issues a 16-byte ATA passthrough CDB via SG_IO and returns the data buffer filled in
+// by the device.
+func (d *SCSIDevice) ataSGIO(feature, count, lbaLow, lbaMid, lbaHigh, command byte, data []byte) error {
+	cdb := [16]byte{
+		0:  _ATA_16,
+		1:  _SG_ATA_PROTO_PIO_IN,
+		2:  0x0e, // length in sector count, read
+		3:  feature,
+		4:  count,
+		5:  0,
+		6:  lbaLow,
+		7:  0,
+		8:  lbaMid,
+		9:  0,
+		10: lbaHigh,
+		12: _ATA_USING_LBA,
+		13: 0,
+		14: command,
+	}
+
+	var sense [32]byte
+
+	hdr := sgIoHdr{
+		interfaceID:    'S',
+		dxferDirection: _SG_DXFER_FROM_DEV,
+		cmdLen:         uint8(len(cdb)),
+		mxSbLen:        uint8(len(sense)),
+		dxferLen:       uint32(len(data)),
+		dxferp:         uintptr(unsafe.Pointer(&data[0])),
+		cmdp:           uintptr(unsafe.Pointer(&cdb[0])),
+		sbp:            uintptr(unsafe.Pointer(&sense[0])),
+		timeout:        20000,
+	}
+
+	// The Pointer->uintptr conversion above must stay valid only until the ioctl syscall that
+	// consumes it; issuing it inline here (rather than threading the pointer through an
+	// intermediate helper like IoctlSetInt) keeps that guarantee intact.
+	_, _, errno := unix.Syscall(unix.SYS_IOCTL, uintptr(d.fd), _SG_IO, uintptr(unsafe.Pointer(&hdr)))
+	if errno != 0 {
+		return errno
+	}
+	return nil
+}
+
+// identify issues an ATA IDENTIFY DEVICE command and returns the raw 512-byte response.
+func (d *SCSIDevice) identify() ([512]byte, error) {
+	var buf [512]byte
+	err := d.ataSGIO(0, 1, 0, 0, 0, _ATA_IDENTIFY_DEVICE, buf[:])
+	return buf, err
+}
+
+// readSMARTValues issues SMART READ DATA (SMART READ VALUES sub-command) and returns the raw
+// 512-byte attribute table.
+func (d *SCSIDevice) readSMARTValues() ([512]byte, error) {
+	var buf [512]byte
+	err := d.ataSGIO(_SMART_READ_VALUES, 1, 0x4f, 0xc2, 0, _ATA_SMART_CMD, buf[:])
+	return buf, err
+}
+
+// readSMARTThresholds issues SMART READ THRESHOLDS and returns the raw 512-byte threshold table.
+func (d *SCSIDevice) readSMARTThresholds() ([512]byte, error) {
+	var buf [512]byte
+	err := d.ataSGIO(_SMART_READ_THRESHOLDS, 1, 0x4f, 0xc2, 0, _ATA_SMART_CMD, buf[:])
+	return buf, err
+}
+
+// PrintSMART reads the device's IDENTIFY and SMART data and writes a human-readable report to w.
+func (d *SCSIDevice) PrintSMART(db *drivedb.DriveDb, w io.Writer) error {
+	report, err := d.SMART(db)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(w, "Device Model:     %s\n", report.ATA.ModelName)
+	fmt.Fprintf(w, "Serial Number:    %s\n", report.ATA.SerialNumber)
+
+	if entry, ok := db.Lookup(report.ATA.ModelName); ok && entry.Family != "" {
+		fmt.Fprintf(w, "Model Family:     %s\n", entry.Family)
+	}
+
+	fmt.Fprintln(w, "\nID# ATTRIBUTE_NAME          FLAG     VALUE WORST THRESH RAW_VALUE")
+	for _, attr := range report.ATAAttributes {
+		fmt.Fprintf(w, "%3d %-23s 0x%04x   %3d   %3d   %3d    %d\n",
+			attr.ID, attr.Name, attr.Flags, attr.Value, attr.Worst, attr.Threshold, attr.RawValue)
+	}
+
+	return nil
+}
+
+// SMART reads the device's IDENTIFY and SMART data and returns it as a typed, JSON-marshalable
+// Report, using db to resolve vendor-specific attribute names.
+func (d *SCSIDevice) SMART(db *drivedb.DriveDb) (smart.Report, error) {
+	report := smart.Report{Device: d.Name}
+
+	ident, err := d.identify()
+	if err != nil {
+		return report, fmt.Errorf("scsi: IDENTIFY failed: %w", err)
+	}
+
+	model := swapBytes(ident[54:94])
+	serial := swapBytes(ident[20:40])
+	firmware := swapBytes(ident[46:54])
+
+	report.ATA = &smart.ATAIdentify{
+		ModelName:       model,
+		SerialNumber:    serial,
+		FirmwareVersion: firmware,
+	}
+
+	entry, _ := db.Lookup(model)
+
+	values, err := d.readSMARTValues()
+	if err != nil {
+		return report, fmt.Errorf("scsi: SMART READ VALUES failed: %w", err)
+	}
+
+	thresholds := map[uint8]uint8{}
+	if buf, err := d.readSMARTThresholds(); err == nil {
+		thresholds = smartThresholds(buf)
+	}
+
+	for i := 0; i < 30; i++ {
+		off := 2 + i*12
+		id := values[off]
+		if id == 0 {
+			continue
+		}
+
+		raw := uint64(0)
+		for b := 0; b < 6; b++ {
+			raw |= uint64(values[off+5+b]) << (8 * uint(b))
+		}
+
+		value, worst, threshold := values[off+3], values[off+4], thresholds[id]
+
+		report.ATAAttributes = append(report.ATAAttributes, smart.AttributeReport{
+			ID:         id,
+			Name:       entry.AttributeName(id),
+			Flags:      uint16(values[off+1]) | uint16(values[off+2])<<8,
+			Value:      value,
+			Worst:      worst,
+			Threshold:  threshold,
+			RawValue:   raw,
+			WhenFailed: whenFailed(value, worst, threshold),
+		})
+	}
+
+	return report, nil
+}
+
+// ReadMetrics reads the device's SMART attribute table and returns it as a transport-agnostic
+// smart.Metrics snapshot.
+func (d *SCSIDevice) ReadMetrics() (smart.Metrics, error) {
+	var m smart.Metrics
+
+	values, err := d.readSMARTValues()
+	if err != nil {
+		return m, fmt.Errorf("scsi: SMART READ VALUES failed: %w", err)
+	}
+
+	for i := 0; i < 30; i++ {
+		off := 2 + i*12
+		id := values[off]
+		if id == 0 {
+			continue
+		}
+
+		raw := uint64(0)
+		for b := 0; b < 6; b++ {
+			raw |= uint64(values[off+5+b]) << (8 * uint(b))
+		}
+
+		// smart.Attribute has no threshold field - Metrics is consumed by the exporter for gauges,
+		// not pass/fail reporting. Per-attribute thresholds are issued and surfaced via SMART
+		// READ THRESHOLDS in SMART's smart.AttributeReport instead.
+		attr := smart.Attribute{
+			ID:    id,
+			Value: values[off+3],
+			Worst: values[off+4],
+			Raw:   raw,
+		}
+		m.Attributes = append(m.Attributes, attr)
+
+		switch id {
+		case _ATTR_POWER_ON_HOURS:
+			m.PowerOnHours = raw
+		case _ATTR_POWER_CYCLE_COUNT:
+			m.PowerCycles = raw
+		case _ATTR_TEMPERATURE_CELSIUS:
+			m.TemperatureCelsius = float64(raw & 0xff)
+		}
+	}
+
+	return m, nil
+}
+
+// swapBytes converts ATA IDENTIFY string fields (which are byte-swapped within each 16-bit word)
+// into a trimmed, human-readable string.
+func swapBytes(b []byte) string {
+	out := make([]byte, len(b))
+	for i := 0; i < len(b); i += 2 {
+		if i+1 < len(b) {
+			out[i], out[i+1] = b[i+1], b[i]
+		}
+	}
+
+	s := string(out)
+	for len(s) > 0 && (s[len(s)-1] == ' ' || s[len(s)-1] == 0) {
+		s = s[:len(s)-1]
+	}
+	return s
+}
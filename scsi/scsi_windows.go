@@ -0,0 +1,244 @@
+// Copyright 2017-18 Daniel Swarbrick. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build windows
+// +build windows
+
+package scsi
+
+import (
+	"fmt"
+	"io"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+
+	"github.com/dswarbrick/smart/drivedb"
+	"github.com/dswarbrick/smart/smart"
+)
+
+const (
+	_SMART_GET_VERSION    = 0x74080
+	_SMART_RCV_DRIVE_DATA = 0x7c088
+
+	_IDE_SMART_READ_ATTRIBUTES = 0xd0
+	_IDE_SMART_READ_THRESHOLDS = 0xd1
+)
+
+// sendCmdInParams mirrors the Windows SENDCMDINPARAMS struct used by the SMART_RCV_DRIVE_DATA
+// IOCTL.
+type sendCmdInParams struct {
+	bufferSize  uint32
+	regs        ideRegs
+	driveNumber byte
+	reserved    [3]byte
+	reservedDw  [4]uint32
+	buffer      byte
+}
+
+type ideRegs struct {
+	features     byte
+	sectorCount  byte
+	sectorNumber byte
+	cylLow       byte
+	cylHigh      byte
+	driveHead    byte
+	command      byte
+	reserved     byte
+}
+
+// SCSIDevice represents an ATA device accessed via the Windows SMART IOCTL API
+// (SMART_RCV_DRIVE_DATA) exposed on \\.\PhysicalDriveN handles.
+type SCSIDevice struct {
+	Name   string
+	handle windows.Handle
+}
+
+// OpenSCSIAutodetect opens name (e.g. "\\.\PhysicalDrive0") for SMART access via DeviceIoControl.
+func OpenSCSIAutodetect(name string) (Device, error) {
+	d := &SCSIDevice{Name: name}
+	if err := d.Open(); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// Open opens a handle to the physical drive with the access rights required to issue
+// DeviceIoControl SMART requests.
+func (d *SCSIDevice) Open() error {
+	p, err := windows.UTF16PtrFromString(d.Name)
+	if err != nil {
+		return err
+	}
+
+	h, err := windows.CreateFile(p, windows.GENERIC_READ|windows.GENERIC_WRITE,
+		windows.FILE_SHARE_READ|windows.FILE_SHARE_WRITE, nil, windows.OPEN_EXISTING, 0, 0)
+	if err != nil {
+		return fmt.Errorf("scsi: CreateFile %s: %w", d.Name, err)
+	}
+
+	d.handle = h
+	return nil
+}
+
+// Close closes the device handle.
+func (d *SCSIDevice) Close() error {
+	return windows.CloseHandle(d.handle)
+}
+
+// readSMARTAttributes issues SMART_RCV_DRIVE_DATA / IDE_SMART_READ_ATTRIBUTES and returns the
+// raw 512-byte attribute table.
+func (d *SCSIDevice) readSMARTAttributes() ([512]byte, error) {
+	return d.smartRcvDriveData(_IDE_SMART_READ_ATTRIBUTES)
+}
+
+// readSMARTThresholds issues SMART_RCV_DRIVE_DATA / IDE_SMART_READ_THRESHOLDS and returns the
+// raw 512-byte threshold table.
+func (d *SCSIDevice) readSMARTThresholds() ([512]byte, error) {
+	return d.smartRcvDriveData(_IDE_SMART_READ_THRESHOLDS)
+}
+
+// smartRcvDriveData issues SMART_RCV_DRIVE_DATA with the given IDE SMART sub-command feature
+// byte and returns the raw 512-byte response.
+func (d *SCSIDevice) smartRcvDriveData(feature byte) ([512]byte, error) {
+	var (
+		in  sendCmdInParams
+		out [sizeofSendCmdOutParamsHeader + 512]byte
+		ret uint32
+	)
+
+	in.driveNumber = 0
+	in.bufferSize = 512
+	in.regs = ideRegs{
+		features: feature,
+		cylLow:   0x4f,
+		cylHigh:  0xc2,
+		command:  0xb0,
+	}
+
+	err := windows.DeviceIoControl(d.handle, _SMART_RCV_DRIVE_DATA,
+		(*byte)(unsafe.Pointer(&in)), uint32(unsafe.Sizeof(in)),
+		&out[0], uint32(len(out)), &ret, nil)
+
+	var buf [512]byte
+	if err != nil {
+		return buf, fmt.Errorf("scsi: SMART_RCV_DRIVE_DATA: %w", err)
+	}
+
+	copy(buf[:], out[sizeofSendCmdOutParamsHeader:])
+	return buf, nil
+}
+
+// sizeofSendCmdOutParamsHeader is the size, in bytes, of the SENDCMDOUTPARAMS header (bufferSize
+// uint32 + DRIVERSTATUS, 4 bytes) that precedes the returned attribute buffer.
+const sizeofSendCmdOutParamsHeader = 8
+
+// PrintSMART reads the device's SMART attribute table via DeviceIoControl and writes a
+// human-readable report to w.
+func (d *SCSIDevice) PrintSMART(db *drivedb.DriveDb, w io.Writer) error {
+	report, err := d.SMART(db)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(w, "Device:           %s\n", report.Device)
+	fmt.Fprintln(w, "\nID# ATTRIBUTE_NAME          VALUE WORST RAW_VALUE")
+	for _, attr := range report.ATAAttributes {
+		fmt.Fprintf(w, "%3d %-23s %3d   %3d   %d\n", attr.ID, attr.Name, attr.Value, attr.Worst, attr.RawValue)
+	}
+
+	return nil
+}
+
+// SMART reads the device's SMART attribute table via DeviceIoControl and returns it as a typed,
+// JSON-marshalable Report. This platform does not issue an IDENTIFY DEVICE equivalent here, so
+// report.ATA is left nil; downstream tooling wanting model/serial should use smart.ScanDevices.
+func (d *SCSIDevice) SMART(db *drivedb.DriveDb) (smart.Report, error) {
+	report := smart.Report{Device: d.Name}
+
+	values, err := d.readSMARTAttributes()
+	if err != nil {
+		return report, err
+	}
+
+	thresholds := map[uint8]uint8{}
+	if buf, err := d.readSMARTThresholds(); err == nil {
+		thresholds = smartThresholds(buf)
+	}
+
+	for i := 0; i < 30; i++ {
+		off := 2 + i*12
+		id := values[off]
+		if id == 0 {
+			continue
+		}
+
+		raw := uint64(0)
+		for b := 0; b < 6; b++ {
+			raw |= uint64(values[off+5+b]) << (8 * uint(b))
+		}
+
+		value, worst, threshold := values[off+3], values[off+4], thresholds[id]
+
+		report.ATAAttributes = append(report.ATAAttributes, smart.AttributeReport{
+			ID:         id,
+			Name:       "Unknown_Attribute",
+			Value:      value,
+			Worst:      worst,
+			Threshold:  threshold,
+			RawValue:   raw,
+			WhenFailed: whenFailed(value, worst, threshold),
+		})
+	}
+
+	return report, nil
+}
+
+// ReadMetrics reads the device's SMART attribute table and returns it as a transport-agnostic
+// smart.Metrics snapshot.
+func (d *SCSIDevice) ReadMetrics() (smart.Metrics, error) {
+	var m smart.Metrics
+
+	values, err := d.readSMARTAttributes()
+	if err != nil {
+		return m, err
+	}
+
+	for i := 0; i < 30; i++ {
+		off := 2 + i*12
+		id := values[off]
+		if id == 0 {
+			continue
+		}
+
+		raw := uint64(0)
+		for b := 0; b < 6; b++ {
+			raw |= uint64(values[off+5+b]) << (8 * uint(b))
+		}
+
+		attr := smart.Attribute{ID: id, Value: values[off+3], Worst: values[off+4], Raw: raw}
+		m.Attributes = append(m.Attributes, attr)
+
+		switch id {
+		case 9:
+			m.PowerOnHours = attr.Raw
+		case 12:
+			m.PowerCycles = attr.Raw
+		case 194:
+			m.TemperatureCelsius = float64(attr.Raw & 0xff)
+		}
+	}
+
+	return m, nil
+}
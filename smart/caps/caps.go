@@ -0,0 +1,27 @@
+// Copyright 2017-18 Daniel Swarbrick. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package caps declares the POSIX capabilities this project's Linux ATA/NVMe passthrough ioctls
+// require, so that callers wanting to check or install them (see cmd/smartctl's checkCaps and
+// -install-caps) have a single, shared definition to work from.
+package caps
+
+import "github.com/syndtr/gocapability/capability"
+
+// Required returns the capabilities needed to issue ATA/NVMe passthrough ioctls without running
+// as root. CAP_SYS_RAWIO covers direct ATA passthrough commands; CAP_SYS_ADMIN covers the
+// SG_IO ioctl on some older kernels that still gate it behind that capability.
+func Required() []capability.Cap {
+	return []capability.Cap{capability.CAP_SYS_RAWIO, capability.CAP_SYS_ADMIN}
+}
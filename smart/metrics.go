@@ -0,0 +1,38 @@
+// Copyright 2017-18 Daniel Swarbrick. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package smart
+
+// Attribute is a single ATA SMART attribute value, as read from the SMART READ DATA response.
+type Attribute struct {
+	ID    uint8
+	Value uint8
+	Worst uint8
+	Raw   uint64
+}
+
+// Metrics is a transport-agnostic snapshot of the numeric health data that matters for
+// monitoring a drive over time: it is populated from the ATA SMART attribute table for SATA/PATA
+// disks, or from the SMART / Health Information log for NVMe controllers. Fields that don't
+// apply to a given transport are left at their zero value. Device.ReadMetrics returns this so
+// that callers such as the exporter package don't need transport-specific code to build gauges.
+type Metrics struct {
+	TemperatureCelsius float64
+	PowerOnHours       uint64
+	PowerCycles        uint64
+	MediaErrors        uint64
+	PercentageUsed     float64
+	AvailableSpare     float64
+	Attributes         []Attribute
+}
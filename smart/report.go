@@ -0,0 +1,85 @@
+// Copyright 2017-18 Daniel Swarbrick. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package smart
+
+// AttributeReport is a single ATA SMART attribute, laid out to match the field names used by
+// upstream smartmontools' `smartctl --json` output (ata_smart_attributes.table[]).
+type AttributeReport struct {
+	ID         uint8  `json:"id"`
+	Name       string `json:"name"`
+	Flags      uint16 `json:"flags"`
+	Value      uint8  `json:"value"`
+	Worst      uint8  `json:"worst"`
+	Threshold  uint8  `json:"thresh"`
+	RawValue   uint64 `json:"raw_value"`
+	WhenFailed string `json:"when_failed,omitempty"`
+}
+
+// ATAIdentify covers the subset of ATA IDENTIFY DEVICE fields that upstream `smartctl --json`
+// surfaces at the top level of its report.
+type ATAIdentify struct {
+	ModelName       string `json:"model_name"`
+	SerialNumber    string `json:"serial_number"`
+	FirmwareVersion string `json:"firmware_version"`
+	WWN             string `json:"wwn,omitempty"`
+}
+
+// NVMeIdentifyController covers the subset of the NVMe Identify Controller data structure that
+// upstream `smartctl --json` surfaces under "nvme_identify_controller_data".
+type NVMeIdentifyController struct {
+	ModelNumber     string `json:"model_number"`
+	SerialNumber    string `json:"serial_number"`
+	FirmwareVersion string `json:"firmware_version"`
+}
+
+// NVMeSMARTLog mirrors the fields upstream `smartctl --json` reports under
+// "nvme_smart_health_information_log". PowerCycles, PowerOnHours and MediaErrors are carried at
+// their full spec-defined 128-bit width ([2]uint64, little-endian: [0] low qword, [1] high
+// qword), matching nvme.SMARTLog, rather than truncated to 64 bits.
+type NVMeSMARTLog struct {
+	CriticalWarning               uint8     `json:"critical_warning"`
+	TemperatureKelvin             uint16    `json:"temperature"`
+	AvailableSpare                uint8     `json:"available_spare"`
+	PercentageUsed                uint8     `json:"percentage_used"`
+	EnduranceGroupCriticalWarning uint8     `json:"endurance_group_critical_warning_summary,omitempty"`
+	PowerCycles                   [2]uint64 `json:"power_cycles"`
+	PowerOnHours                  [2]uint64 `json:"power_on_hours"`
+	MediaErrors                   [2]uint64 `json:"media_errors"`
+	TemperatureSensors            [8]uint16 `json:"temperature_sensors,omitempty"`
+}
+
+// NVMeNamespace mirrors the per-entry fields upstream `smartctl --json` reports under
+// "nvme_namespaces", one per namespace returned by nvme.NVMeDevice.Identify.
+type NVMeNamespace struct {
+	ID          uint32 `json:"id"`
+	Size        uint64 `json:"size"`
+	Capacity    uint64 `json:"capacity"`
+	Utilization uint64 `json:"utilization"`
+}
+
+// Report is the typed, JSON-marshalable result of reading a device's SMART data, returned by
+// Device.SMART(). Only the fields relevant to the device's transport are populated; the rest are
+// left at their zero value and omitted from the JSON encoding.
+type Report struct {
+	Device string `json:"device"`
+
+	ATA           *ATAIdentify      `json:"ata_identify,omitempty"`
+	ATAAttributes []AttributeReport `json:"ata_smart_attributes,omitempty"`
+
+	NVMeController         *NVMeIdentifyController `json:"nvme_identify_controller_data,omitempty"`
+	NVMeSMARTLog           *NVMeSMARTLog           `json:"nvme_smart_health_information_log,omitempty"`
+	NVMeNumberOfNamespaces uint32                  `json:"nvme_number_of_namespaces,omitempty"`
+	NVMeNamespaces         []NVMeNamespace         `json:"nvme_namespaces,omitempty"`
+}
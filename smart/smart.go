@@ -0,0 +1,37 @@
+// Copyright 2017-18 Daniel Swarbrick. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package smart discovers SMART-capable storage devices attached to the host.
+package smart
+
+// DeviceInfo describes a single SMART-capable device discovered by ScanDevices.
+type DeviceInfo struct {
+	Name   string
+	Model  string
+	Serial string
+	WWN    string
+}
+
+// ID returns a DeviceID summarizing this device's stable identity, for use as a map key or
+// metric label set.
+func (d DeviceInfo) ID() DeviceID {
+	return DeviceID{model: d.Model, serial: d.Serial, wwn: d.WWN}
+}
+
+// ScanDevices enumerates the SATA and NVMe devices attached to the host that support SMART.
+// Enumeration is platform-specific; see scanDevices in smart_linux.go, smart_darwin.go and
+// smart_windows.go.
+func ScanDevices() []DeviceInfo {
+	return scanDevices()
+}
@@ -0,0 +1,96 @@
+// Copyright 2017-18 Daniel Swarbrick. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build darwin
+// +build darwin
+
+package smart
+
+import (
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"regexp"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+// wholeDiskRe matches whole-disk BSD device nodes (e.g. "disk0"), as opposed to partition/slice
+// nodes (e.g. "disk0s1"), which are not independently SMART-queryable.
+var wholeDiskRe = regexp.MustCompile(`^disk[0-9]+$`)
+
+// _DKIOCGETIDENTIFYDATA is the IOKit ioctl that returns the raw 512-byte ATA IDENTIFY DEVICE
+// response for a disk object managed by the IOATABlockStorageDriver / AppleAHCIDiskDriver
+// family, analogous to the DKIOCSMARTREADDATA ioctl the scsi package uses to read the SMART
+// attribute table. See <IOKit/storage/ata/ATASMARTLib.h>.
+const _DKIOCGETIDENTIFYDATA = 0xc0187318
+
+// scanDevices enumerates whole-disk BSD device nodes under /dev, deriving each one's model,
+// serial and WWN from ATA IDENTIFY DEVICE data read via IOKit.
+func scanDevices() []DeviceInfo {
+	var devices []DeviceInfo
+
+	matches, err := filepath.Glob("/dev/disk*")
+	if err != nil {
+		return devices
+	}
+
+	for _, path := range matches {
+		if !wholeDiskRe.MatchString(filepath.Base(path)) {
+			continue
+		}
+
+		model, serial, wwn := ataIdentity(path)
+		devices = append(devices, DeviceInfo{Name: path, Model: model, Serial: serial, WWN: wwn})
+	}
+
+	return devices
+}
+
+// ataIdentity opens path and issues ATA IDENTIFY DEVICE via IOKit to derive a drive's model,
+// serial and WWN. If the device doesn't support the ioctl (e.g. it isn't a SMART-capable
+// IOATABlockStorageDriver node) or doesn't report a WWN, it falls back to a WWN synthesized from
+// whatever identifying information was recovered, keyed on path as a last resort so that
+// distinct BSD device nodes never collapse to the same identity.
+func ataIdentity(path string) (model, serial, wwn string) {
+	f, err := os.OpenFile(path, os.O_RDWR, 0)
+	if err != nil {
+		return "", "", synthesizeWWN([3]byte{}, path)
+	}
+	defer f.Close()
+
+	var ident [512]byte
+	if _, _, errno := unix.Syscall(unix.SYS_IOCTL, f.Fd(), _DKIOCGETIDENTIFYDATA, uintptr(unsafe.Pointer(&ident[0]))); errno != 0 {
+		return "", "", synthesizeWWN([3]byte{}, path)
+	}
+
+	model = ataIdentifyString(ident[54:94])
+	serial = ataIdentifyString(ident[20:40])
+
+	w108 := binary.LittleEndian.Uint16(ident[216:218])
+	w109 := binary.LittleEndian.Uint16(ident[218:220])
+	w110 := binary.LittleEndian.Uint16(ident[220:222])
+	w111 := binary.LittleEndian.Uint16(ident[222:224])
+
+	if wwn = wwnFromATAIdentifyWords(w108, w109, w110, w111); wwn == "" {
+		key := serial
+		if key == "" {
+			key = path
+		}
+		wwn = synthesizeWWN(ouiForModel(model), key)
+	}
+
+	return model, serial, wwn
+}
@@ -0,0 +1,284 @@
+// Copyright 2017-18 Daniel Swarbrick. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build linux
+// +build linux
+
+package smart
+
+import (
+	"encoding/binary"
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+// nvmeCtrlRe matches NVMe controller device names (e.g. "nvme0"), as listed under
+// /sys/class/nvme, as opposed to namespace block device names (e.g. "nvme0n1") listed under
+// /sys/block. The controller node is what the nvme package issues admin commands against.
+var nvmeCtrlRe = regexp.MustCompile(`^nvme[0-9]+$`)
+
+// scanDevices walks /sys/block for SATA (sdX) disks and /sys/class/nvme for NVMe controllers
+// (nvmeX), and queries each one for its model and serial via the corresponding sysfs attributes.
+func scanDevices() []DeviceInfo {
+	var devices []DeviceInfo
+
+	entries, err := ioutil.ReadDir("/sys/block")
+	if err != nil {
+		return devices
+	}
+
+	if ctrls, err := ioutil.ReadDir("/sys/class/nvme"); err == nil {
+		entries = append(entries, ctrls...)
+	}
+
+	for _, entry := range entries {
+		name := entry.Name()
+
+		switch {
+		case strings.HasPrefix(name, "sd"):
+			path := "/dev/" + name
+			model := readSysfsAttr(filepath.Join("/sys/block", name, "device", "model"))
+			serial := readSysfsAttr(filepath.Join("/sys/block", name, "device", "serial"))
+			devices = append(devices, DeviceInfo{
+				Name:   path,
+				Model:  model,
+				Serial: serial,
+				WWN:    ataWWN(path, model, serial),
+			})
+		case nvmeCtrlRe.MatchString(name):
+			path := "/dev/" + name
+			model := readSysfsAttr(filepath.Join("/sys/class/nvme", name, "model"))
+			serial := readSysfsAttr(filepath.Join("/sys/class/nvme", name, "serial"))
+			devices = append(devices, DeviceInfo{
+				Name:   path,
+				Model:  model,
+				Serial: serial,
+				WWN:    nvmeWWN(path, model, serial),
+			})
+		}
+	}
+
+	return devices
+}
+
+// ataWWN opens path and issues ATA IDENTIFY DEVICE to read WWN words 108-111, falling back to a
+// synthesized WWN if the drive doesn't report one (or the IDENTIFY fails, e.g. due to
+// insufficient capabilities).
+func ataWWN(path, model, serial string) string {
+	fd, err := unix.Open(path, unix.O_RDONLY, 0)
+	if err != nil {
+		return synthesizeWWN(ouiForModel(model), serial)
+	}
+	defer unix.Close(fd)
+
+	ident, err := ataIdentify(fd)
+	if err != nil {
+		return synthesizeWWN(ouiForModel(model), serial)
+	}
+
+	w108 := binary.LittleEndian.Uint16(ident[216:218])
+	w109 := binary.LittleEndian.Uint16(ident[218:220])
+	w110 := binary.LittleEndian.Uint16(ident[220:222])
+	w111 := binary.LittleEndian.Uint16(ident[222:224])
+
+	if wwn := wwnFromATAIdentifyWords(w108, w109, w110, w111); wwn != "" {
+		return wwn
+	}
+	return synthesizeWWN(ouiForModel(model), serial)
+}
+
+// ataIdentify issues ATA IDENTIFY DEVICE via the SG_IO ATA passthrough ioctl and returns the raw
+// 512-byte response.
+func ataIdentify(fd int) ([512]byte, error) {
+	const (
+		_SG_IO               = 0x2285
+		_ATA_16              = 0x85
+		_SG_ATA_PROTO_PIO_IN = 4 << 1
+		_ATA_USING_LBA       = 0x40
+		_ATA_IDENTIFY_DEVICE = 0xec
+
+		// _SG_DXFER_FROM_DEV is Linux's SG_DXFER_FROM_DEV; golang.org/x/sys/unix does not expose
+		// the SCSI generic ioctl interface, see <scsi/sg.h>.
+		_SG_DXFER_FROM_DEV = -3
+	)
+
+	// sgIoHdr mirrors the kernel's sg_io_hdr struct used by the SG_IO ioctl, which
+	// golang.org/x/sys/unix does not define; see scsi/scsi_linux.go for the same layout.
+	type sgIoHdr struct {
+		interfaceID    int32
+		dxferDirection int32
+		cmdLen         uint8
+		mxSbLen        uint8
+		iovecCount     uint16
+		dxferLen       uint32
+		dxferp         uintptr
+		cmdp           uintptr
+		sbp            uintptr
+		timeout        uint32
+		flags          uint32
+		packID         int32
+		usrPtr         uintptr
+		status         uint8
+		maskedStatus   uint8
+		msgStatus      uint8
+		sbLenWr        uint8
+		hostStatus     uint16
+		driverStatus   uint16
+		resid          int32
+		duration       uint32
+		info           uint32
+	}
+
+	var buf [512]byte
+	var sense [32]byte
+
+	cdb := [16]byte{0: _ATA_16, 1: _SG_ATA_PROTO_PIO_IN, 2: 0x0e, 4: 1, 12: _ATA_USING_LBA, 14: _ATA_IDENTIFY_DEVICE}
+
+	hdr := sgIoHdr{
+		interfaceID:    'S',
+		dxferDirection: _SG_DXFER_FROM_DEV,
+		cmdLen:         uint8(len(cdb)),
+		mxSbLen:        uint8(len(sense)),
+		dxferLen:       uint32(len(buf)),
+		dxferp:         uintptr(unsafe.Pointer(&buf[0])),
+		cmdp:           uintptr(unsafe.Pointer(&cdb[0])),
+		sbp:            uintptr(unsafe.Pointer(&sense[0])),
+		timeout:        20000,
+	}
+
+	// Issue the syscall directly (rather than through IoctlSetInt's int parameter) so the
+	// Pointer->uintptr conversion above remains valid for the syscall that consumes it.
+	_, _, errno := unix.Syscall(unix.SYS_IOCTL, uintptr(fd), _SG_IO, uintptr(unsafe.Pointer(&hdr)))
+	if errno != 0 {
+		return buf, errno
+	}
+	return buf, nil
+}
+
+// nvmeWWN opens path, lists its active namespaces, and issues Identify Namespace (CNS=0) against
+// the first one to read the NGUID/EUI64 fields, falling back to a synthesized WWN if the
+// namespace doesn't report one. NGUID/EUI64 live in the Identify Namespace structure, not
+// Identify Controller (CNS=1) - a controller has no WWN of its own, only its namespaces do.
+func nvmeWWN(path, model, serial string) string {
+	fd, err := unix.Open(path, unix.O_RDONLY, 0)
+	if err != nil {
+		return synthesizeWWN(ouiForModel(model), serial)
+	}
+	defer unix.Close(fd)
+
+	nsids, err := nvmeListActiveNamespaces(fd)
+	if err != nil || len(nsids) == 0 {
+		return synthesizeWWN(ouiForModel(model), serial)
+	}
+
+	ident, err := nvmeIdentifyNamespace(fd, nsids[0])
+	if err != nil {
+		return synthesizeWWN(ouiForModel(model), serial)
+	}
+
+	if wwn := wwnFromNVMeIdentify(ident[:]); wwn != "" {
+		return wwn
+	}
+	return synthesizeWWN(ouiForModel(model), serial)
+}
+
+const _NVME_IOCTL_ADMIN_CMD = 0xc0484e41
+
+// nvmePassthruCmd mirrors struct nvme_admin_cmd from <linux/nvme_ioctl.h>; see
+// nvme/nvme_linux.go for the same layout.
+type nvmePassthruCmd struct {
+	opcode      uint8
+	flags       uint8
+	rsvd1       uint16
+	nsid        uint32
+	cdw2        uint32
+	cdw3        uint32
+	metadata    uint64
+	addr        uint64
+	metadataLen uint32
+	dataLen     uint32
+	cdw10       uint32
+	cdw11       uint32
+	cdw12       uint32
+	cdw13       uint32
+	cdw14       uint32
+	cdw15       uint32
+	timeoutMs   uint32
+	result      uint32
+}
+
+// nvmeIdentify issues an Identify command (opcode 0x06) with the given CNS value and namespace
+// ID via the NVMe passthrough ioctl, and returns the raw 4096-byte response.
+func nvmeIdentify(fd int, cns, nsid uint32) ([4096]byte, error) {
+	var buf [4096]byte
+	cmd := nvmePassthruCmd{
+		opcode:  0x06, // Identify
+		nsid:    nsid,
+		addr:    uint64(uintptr(unsafe.Pointer(&buf[0]))),
+		dataLen: uint32(len(buf)),
+		cdw10:   cns,
+	}
+
+	// Issue the syscall directly (rather than through IoctlSetInt's int parameter) so the
+	// Pointer->uintptr conversion of &cmd remains valid for the syscall that consumes it.
+	_, _, errno := unix.Syscall(unix.SYS_IOCTL, uintptr(fd), _NVME_IOCTL_ADMIN_CMD, uintptr(unsafe.Pointer(&cmd)))
+	if errno != 0 {
+		return buf, errno
+	}
+	return buf, nil
+}
+
+// nvmeListActiveNamespaces issues Identify Active Namespace ID List (CNS=2) and returns the
+// active namespace IDs, in ascending order, terminated in the response by the first zero entry.
+func nvmeListActiveNamespaces(fd int) ([]uint32, error) {
+	const _NVME_IDENTIFY_CNS_ACTIVE_NS_LIST = 0x02
+
+	buf, err := nvmeIdentify(fd, _NVME_IDENTIFY_CNS_ACTIVE_NS_LIST, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	var nsids []uint32
+	for off := 0; off < len(buf); off += 4 {
+		nsid := binary.LittleEndian.Uint32(buf[off : off+4])
+		if nsid == 0 {
+			break
+		}
+		nsids = append(nsids, nsid)
+	}
+
+	return nsids, nil
+}
+
+// nvmeIdentifyNamespace issues Identify Namespace (CNS=0) for nsid and returns the raw 4096-byte
+// Identify Namespace data structure, which is where a namespace's NGUID/EUI64 live.
+func nvmeIdentifyNamespace(fd int, nsid uint32) ([4096]byte, error) {
+	const _NVME_IDENTIFY_CNS_NAMESPACE = 0x00
+	return nvmeIdentify(fd, _NVME_IDENTIFY_CNS_NAMESPACE, nsid)
+}
+
+// readSysfsAttr reads a single-line sysfs attribute file, returning an empty string if it
+// cannot be read.
+func readSysfsAttr(path string) string {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(b))
+}
@@ -0,0 +1,135 @@
+// Copyright 2017-18 Daniel Swarbrick. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build windows
+// +build windows
+
+package smart
+
+import (
+	"encoding/binary"
+	"fmt"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+const (
+	_SMART_RCV_DRIVE_DATA = 0x7c088
+	_IDE_IDENTIFY_DEVICE  = 0xec
+
+	// sizeofSendCmdOutParamsHeader is the size, in bytes, of the SENDCMDOUTPARAMS header
+	// (bufferSize uint32 + DRIVERSTATUS, 4 bytes) that precedes the returned data buffer.
+	sizeofSendCmdOutParamsHeader = 8
+)
+
+// sendCmdInParams mirrors the Windows SENDCMDINPARAMS struct used by the SMART_RCV_DRIVE_DATA
+// IOCTL, duplicated here (rather than imported from the scsi package) because it is an
+// unexported implementation detail of that IOCTL, not part of the scsi.Device API.
+type sendCmdInParams struct {
+	bufferSize  uint32
+	regs        ideRegs
+	driveNumber byte
+	reserved    [3]byte
+	reservedDw  [4]uint32
+	buffer      byte
+}
+
+type ideRegs struct {
+	features     byte
+	sectorCount  byte
+	sectorNumber byte
+	cylLow       byte
+	cylHigh      byte
+	driveHead    byte
+	command      byte
+	reserved     byte
+}
+
+// openPhysicalDrive opens name (e.g. "\\.\PhysicalDrive0") for SMART_RCV_DRIVE_DATA access,
+// returning windows.InvalidHandle if the device node doesn't exist or isn't accessible.
+func openPhysicalDrive(name string) (windows.Handle, error) {
+	p, err := windows.UTF16PtrFromString(name)
+	if err != nil {
+		return windows.InvalidHandle, err
+	}
+
+	return windows.CreateFile(p, windows.GENERIC_READ|windows.GENERIC_WRITE,
+		windows.FILE_SHARE_READ|windows.FILE_SHARE_WRITE, nil, windows.OPEN_EXISTING, 0, 0)
+}
+
+// scanDevices enumerates physical drives \\.\PhysicalDrive0 through \\.\PhysicalDrive31,
+// probing each one with CreateFile to determine whether it exists, and deriving model, serial
+// and WWN from each one's IDENTIFY DEVICE data.
+func scanDevices() []DeviceInfo {
+	var devices []DeviceInfo
+
+	for i := 0; i < 32; i++ {
+		name := fmt.Sprintf(`\\.\PhysicalDrive%d`, i)
+
+		h, err := openPhysicalDrive(name)
+		if err != nil {
+			continue
+		}
+
+		model, serial, wwn := ataIdentity(h, name)
+		windows.CloseHandle(h)
+
+		devices = append(devices, DeviceInfo{Name: name, Model: model, Serial: serial, WWN: wwn})
+	}
+
+	return devices
+}
+
+// ataIdentity issues IDENTIFY DEVICE via SMART_RCV_DRIVE_DATA on the already-open handle h to
+// derive a drive's model, serial and WWN. If the IOCTL fails or the drive doesn't report a WWN,
+// it falls back to a WWN synthesized from whatever identifying information was recovered, keyed
+// on name as a last resort so that distinct physical drives never collapse to the same identity.
+func ataIdentity(h windows.Handle, name string) (model, serial, wwn string) {
+	var (
+		in  sendCmdInParams
+		out [sizeofSendCmdOutParamsHeader + 512]byte
+		ret uint32
+	)
+
+	in.bufferSize = 512
+	in.regs = ideRegs{command: _IDE_IDENTIFY_DEVICE}
+
+	if err := windows.DeviceIoControl(h, _SMART_RCV_DRIVE_DATA,
+		(*byte)(unsafe.Pointer(&in)), uint32(unsafe.Sizeof(in)),
+		&out[0], uint32(len(out)), &ret, nil); err != nil {
+		return "", "", synthesizeWWN([3]byte{}, name)
+	}
+
+	var ident [512]byte
+	copy(ident[:], out[sizeofSendCmdOutParamsHeader:])
+
+	model = ataIdentifyString(ident[54:94])
+	serial = ataIdentifyString(ident[20:40])
+
+	w108 := binary.LittleEndian.Uint16(ident[216:218])
+	w109 := binary.LittleEndian.Uint16(ident[218:220])
+	w110 := binary.LittleEndian.Uint16(ident[220:222])
+	w111 := binary.LittleEndian.Uint16(ident[222:224])
+
+	if wwn = wwnFromATAIdentifyWords(w108, w109, w110, w111); wwn == "" {
+		key := serial
+		if key == "" {
+			key = name
+		}
+		wwn = synthesizeWWN(ouiForModel(model), key)
+	}
+
+	return model, serial, wwn
+}
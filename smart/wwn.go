@@ -0,0 +1,124 @@
+// Copyright 2017-18 Daniel Swarbrick. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package smart
+
+import (
+	"crypto/sha1"
+	"encoding/binary"
+	"fmt"
+	"strings"
+)
+
+// DeviceID is an immutable, comparable identity for a single physical drive, suitable for use as
+// a map key or metric label set by downstream tooling (e.g. a Prometheus exporter) that needs to
+// correlate a drive across reboots and /dev path changes.
+type DeviceID struct {
+	model  string
+	serial string
+	wwn    string
+}
+
+// WWN returns the drive's World-Wide Name as "naa." followed by 16 hex digits, or 32 hex digits
+// when it was taken from an NVMe namespace's NGUID.
+func (id DeviceID) WWN() string { return id.wwn }
+
+// Serial returns the drive's serial number, as reported by IDENTIFY DEVICE or Identify
+// Controller.
+func (id DeviceID) Serial() string { return id.serial }
+
+// Model returns the drive's model string.
+func (id DeviceID) Model() string { return id.model }
+
+// wwnFromATAIdentifyWords derives a WWN from ATA IDENTIFY DEVICE words 108-111, which encode a
+// NAA designator when the device supports the World Wide Name feature set (word 84/87 bit 8).
+// Returns an empty string if the words are all zero (i.e. the device did not report a WWN).
+func wwnFromATAIdentifyWords(w108, w109, w110, w111 uint16) string {
+	if w108 == 0 && w109 == 0 && w110 == 0 && w111 == 0 {
+		return ""
+	}
+	return fmt.Sprintf("naa.%04x%04x%04x%04x", w108, w109, w110, w111)
+}
+
+// wwnFromNVMeIdentify derives a WWN from the NVMe Identify Namespace data structure's NGUID
+// (bytes 104-119) or EUI64 (bytes 120-127) fields, per the NVMe base specification's layout of
+// CNS=0x00. These identify a namespace, not a controller, so ident must come from an Identify
+// Namespace command (CNS=0), not Identify Controller (CNS=1). NGUID is preferred when present,
+// falling back to EUI64.
+func wwnFromNVMeIdentify(ident []byte) string {
+	nguid := ident[104:120]
+	if !allZero(nguid) {
+		return fmt.Sprintf("naa.%x", nguid)
+	}
+
+	eui64 := ident[120:128]
+	if !allZero(eui64) {
+		return fmt.Sprintf("naa.%x", eui64)
+	}
+
+	return ""
+}
+
+// ataIdentifyString decodes an ASCII string field (e.g. model number, serial number) from ATA
+// IDENTIFY DEVICE data, where each 16-bit word holds two characters in byte-swapped order, per
+// the ATA/ATAPI command set. Platforms that read IDENTIFY DEVICE data directly (smart_darwin.go,
+// smart_windows.go) use this; Linux instead reads the already-formatted strings from sysfs.
+func ataIdentifyString(raw []byte) string {
+	buf := make([]byte, len(raw))
+	for i := 0; i+1 < len(raw); i += 2 {
+		buf[i], buf[i+1] = raw[i+1], raw[i]
+	}
+	return strings.TrimSpace(string(buf))
+}
+
+// ouiForModel returns a best-effort IEEE OUI for the given drive model string, for use when
+// synthesizing a fallback WWN. Unrecognized models return the all-zero OUI.
+func ouiForModel(model string) [3]byte {
+	switch {
+	case strings.Contains(model, "Samsung"):
+		return [3]byte{0x34, 0x80, 0x0d}
+	case strings.Contains(model, "ST") && strings.HasPrefix(model, "ST"):
+		return [3]byte{0x00, 0x0c, 0x50} // Seagate
+	case strings.Contains(model, "WDC"):
+		return [3]byte{0x00, 0x14, 0xee} // Western Digital
+	default:
+		return [3]byte{}
+	}
+}
+
+func allZero(b []byte) bool {
+	for _, v := range b {
+		if v != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// synthesizeWWN builds a deterministic NAA IEEE Extended (NAA type 5) WWN for drives that don't
+// advertise one: a 4-bit NAA type (0x5), a 24-bit IEEE OUI, and a 36-bit vendor-specific ID
+// derived from the drive's serial number. Because the vendor-specific field is a hash rather
+// than a true per-vendor sequence number, this is only unique in practice, not guaranteed unique
+// against the real IEEE registry - but it is stable across reboots for a given (oui, serial)
+// pair, which is the property downstream tooling needs.
+func synthesizeWWN(oui [3]byte, serial string) string {
+	h := sha1.Sum([]byte(serial))
+	vendorSpecific := binary.BigEndian.Uint64(append([]byte{0, 0, 0}, h[:5]...)) & 0xfffffffff
+
+	naa := uint64(0x5)
+	ouiVal := uint64(oui[0])<<16 | uint64(oui[1])<<8 | uint64(oui[2])
+
+	wwn := (naa << 60) | (ouiVal << 36) | vendorSpecific
+	return fmt.Sprintf("naa.%016x", wwn)
+}
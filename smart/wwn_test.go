@@ -0,0 +1,106 @@
+// Copyright 2017-18 Daniel Swarbrick. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package smart
+
+import "testing"
+
+func TestWWNFromATAIdentifyWords(t *testing.T) {
+	tests := []struct {
+		name                   string
+		w108, w109, w110, w111 uint16
+		want                   string
+	}{
+		{"all zero", 0, 0, 0, 0, ""},
+		{"naa designator", 0x5000, 0xc500, 0x12ab, 0xcdef, "naa.5000c50012abcdef"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := wwnFromATAIdentifyWords(tt.w108, tt.w109, tt.w110, tt.w111); got != tt.want {
+				t.Errorf("wwnFromATAIdentifyWords(%#x, %#x, %#x, %#x) = %q, want %q",
+					tt.w108, tt.w109, tt.w110, tt.w111, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWWNFromNVMeIdentify(t *testing.T) {
+	// NGUID (bytes 104-119) and EUI64 (bytes 120-127) are adjacent, non-overlapping fields of the
+	// Identify Namespace (CNS=0) data structure.
+	nguid := make([]byte, 4096)
+	copy(nguid[104:120], []byte{0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08, 0x09, 0x0a, 0x0b, 0x0c, 0x0d, 0x0e, 0x0f, 0x10})
+	copy(nguid[120:128], []byte{0xaa, 0xbb, 0xcc, 0xdd, 0xee, 0xff, 0x00, 0x11})
+
+	eui64 := make([]byte, 4096)
+	copy(eui64[120:128], []byte{0xaa, 0xbb, 0xcc, 0xdd, 0xee, 0xff, 0x00, 0x11})
+
+	tests := []struct {
+		name  string
+		ident []byte
+		want  string
+	}{
+		{"neither present", make([]byte, 4096), ""},
+		{"nguid preferred over eui64", nguid, "naa.0102030405060708090a0b0c0d0e0f10"},
+		{"falls back to eui64", eui64, "naa.aabbccddeeff0011"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := wwnFromNVMeIdentify(tt.ident); got != tt.want {
+				t.Errorf("wwnFromNVMeIdentify() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSynthesizeWWN(t *testing.T) {
+	seagateOUI := ouiForModel("ST4000DM004")
+
+	// synthesizeWWN must be deterministic for a given (oui, serial) pair, and must not collide
+	// across different serials.
+	a := synthesizeWWN(seagateOUI, "ZFN1AB2C")
+	b := synthesizeWWN(seagateOUI, "ZFN1AB2C")
+	c := synthesizeWWN(seagateOUI, "ZFN1XYZ9")
+
+	if a != b {
+		t.Errorf("synthesizeWWN not deterministic: %q != %q", a, b)
+	}
+	if a == c {
+		t.Errorf("synthesizeWWN collided for different serials: %q", a)
+	}
+	if want := "naa.5"; a[:len(want)] != want {
+		t.Errorf("synthesizeWWN() = %q, want NAA type 5 prefix %q", a, want)
+	}
+}
+
+func TestOUIForModel(t *testing.T) {
+	tests := []struct {
+		model string
+		want  [3]byte
+	}{
+		{"Samsung SSD 870 EVO", [3]byte{0x34, 0x80, 0x0d}},
+		{"ST4000DM004", [3]byte{0x00, 0x0c, 0x50}},
+		{"WDC WD40EFRX", [3]byte{0x00, 0x14, 0xee}},
+		{"Unknown Vendor Model", [3]byte{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.model, func(t *testing.T) {
+			if got := ouiForModel(tt.model); got != tt.want {
+				t.Errorf("ouiForModel(%q) = %#v, want %#v", tt.model, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,43 @@
+// Copyright 2017-18 Daniel Swarbrick. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build linux
+// +build linux
+
+package aacraid
+
+import (
+	"fmt"
+
+	"github.com/dswarbrick/smart/scsi"
+	"github.com/dswarbrick/smart/transport"
+)
+
+// _FSACTL_SEND_RAW_SRB is the ioctl request code exposed by the aacraid driver for issuing a raw
+// SCSI Request Block to a physical drive behind the controller, see <linux/aacraid/aacraid.h>.
+const _FSACTL_SEND_RAW_SRB = 0xc0285001
+
+// Scan implements transport.Driver. A full implementation issues FSACTL_SEND_RAW_SRB with a
+// REPORT PHYSICAL LUNS SRB against each aacraid-managed host to enumerate physical drives;
+// lacking real hardware to validate that against, this reference implementation reports the
+// enumeration as not implemented rather than silently claiming zero attached drives.
+func (driver) Scan() ([]transport.Target, error) {
+	return nil, fmt.Errorf("aacraid: physical drive enumeration via FSACTL_SEND_RAW_SRB (0x%x) is not yet implemented", _FSACTL_SEND_RAW_SRB)
+}
+
+// Open implements transport.Driver. A full implementation would return a scsi.Device that issues
+// ATA SMART SRBs via _FSACTL_SEND_RAW_SRB against the physical drive addressed by target.
+func (driver) Open(target transport.Target) (scsi.Device, error) {
+	return nil, fmt.Errorf("aacraid: SMART passthrough is not yet implemented")
+}
@@ -0,0 +1,37 @@
+// Copyright 2017-18 Daniel Swarbrick. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !linux
+// +build !linux
+
+package aacraid
+
+import (
+	"errors"
+	"runtime"
+
+	"github.com/dswarbrick/smart/scsi"
+	"github.com/dswarbrick/smart/transport"
+)
+
+// ErrUnsupported is returned by every operation in this package on platforms other than Linux,
+// since the aacraid driver's FSACTL_SEND_RAW_SRB ioctl is a Linux kernel driver feature with no
+// equivalent on Darwin or Windows.
+var ErrUnsupported = errors.New("aacraid: not supported on " + runtime.GOOS)
+
+// Scan always returns ErrUnsupported on non-Linux platforms.
+func (driver) Scan() ([]transport.Target, error) { return nil, ErrUnsupported }
+
+// Open always returns ErrUnsupported on non-Linux platforms.
+func (driver) Open(target transport.Target) (scsi.Device, error) { return nil, ErrUnsupported }
@@ -0,0 +1,33 @@
+// Copyright 2017-18 Daniel Swarbrick. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package areca registers a transport driver for physical drives behind an Areca RAID
+// controller, addressed via vendor-specific SCSI CDBs over the Linux SCSI generic (/dev/sg*)
+// interface. On Linux, Scan and Open (areca_linux.go) return errors: neither drive enumeration
+// nor SMART passthrough is performed. areca_other.go returns ErrUnsupported on other platforms,
+// where the /dev/sg* interface does not exist.
+package areca
+
+import "github.com/dswarbrick/smart/transport"
+
+func init() {
+	transport.Register(driver{})
+}
+
+// driver implements transport.Driver; Scan and Open are defined per-platform in areca_linux.go
+// and areca_other.go.
+type driver struct{}
+
+// Name implements transport.Driver.
+func (driver) Name() string { return "areca" }
@@ -0,0 +1,48 @@
+// Copyright 2017-18 Daniel Swarbrick. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build linux
+// +build linux
+
+package areca
+
+import (
+	"fmt"
+
+	"github.com/dswarbrick/smart/scsi"
+	"github.com/dswarbrick/smart/transport"
+)
+
+// Areca's vendor-specific passthrough opcode and sub-function used to address a physical drive's
+// SMART data through the RAID controller, per the ARC-1xxx/ARC-8xxx CLI protocol.
+const (
+	_ARECA_CDB_OPCODE       = 0x22
+	_ARECA_SUBFUNC_SMART_RW = 0x1c
+)
+
+// Scan implements transport.Driver. A full implementation enumerates /dev/sg* nodes, issues the
+// Areca "get RAID info" CDB against each, and maps returned enclosure/slot pairs to
+// Target.Host/Disk; this reference implementation does not have real hardware to validate that
+// against, so it reports the enumeration as not implemented rather than silently claiming zero
+// attached drives.
+func (driver) Scan() ([]transport.Target, error) {
+	return nil, fmt.Errorf("areca: physical drive enumeration via CDB 0x%x is not yet implemented", _ARECA_CDB_OPCODE)
+}
+
+// Open implements transport.Driver. A full implementation would return a scsi.Device that issues
+// _ARECA_CDB_OPCODE / _ARECA_SUBFUNC_SMART_RW against the enclosure/slot addressed by target over
+// the host adapter's /dev/sg* node.
+func (driver) Open(target transport.Target) (scsi.Device, error) {
+	return nil, fmt.Errorf("areca: SMART passthrough is not yet implemented")
+}
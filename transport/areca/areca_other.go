@@ -0,0 +1,36 @@
+// Copyright 2017-18 Daniel Swarbrick. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !linux
+// +build !linux
+
+package areca
+
+import (
+	"errors"
+	"runtime"
+
+	"github.com/dswarbrick/smart/scsi"
+	"github.com/dswarbrick/smart/transport"
+)
+
+// ErrUnsupported is returned by every operation in this package on platforms other than Linux,
+// since it depends on the Linux SCSI generic (/dev/sg*) interface.
+var ErrUnsupported = errors.New("areca: not supported on " + runtime.GOOS)
+
+// Scan always returns ErrUnsupported on non-Linux platforms.
+func (driver) Scan() ([]transport.Target, error) { return nil, ErrUnsupported }
+
+// Open always returns ErrUnsupported on non-Linux platforms.
+func (driver) Open(target transport.Target) (scsi.Device, error) { return nil, ErrUnsupported }
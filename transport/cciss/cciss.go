@@ -0,0 +1,33 @@
+// Copyright 2017-18 Daniel Swarbrick. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package cciss registers a transport driver for physical drives behind an HP/HPE Smart Array
+// controller, addressed via the cciss/hpsa driver's CCISS_PASSTHRU ioctl. On Linux, Scan and
+// Open (cciss_linux.go) return errors: neither drive enumeration nor SMART passthrough is
+// performed. cciss_other.go returns ErrUnsupported on other platforms, where this Linux kernel
+// driver ioctl has no equivalent.
+package cciss
+
+import "github.com/dswarbrick/smart/transport"
+
+func init() {
+	transport.Register(driver{})
+}
+
+// driver implements transport.Driver; Scan and Open are defined per-platform in cciss_linux.go
+// and cciss_other.go.
+type driver struct{}
+
+// Name implements transport.Driver.
+func (driver) Name() string { return "cciss" }
@@ -0,0 +1,43 @@
+// Copyright 2017-18 Daniel Swarbrick. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build linux
+// +build linux
+
+package cciss
+
+import (
+	"fmt"
+
+	"github.com/dswarbrick/smart/scsi"
+	"github.com/dswarbrick/smart/transport"
+)
+
+// _CCISS_PASSTHRU is the ioctl request code exposed by the cciss and hpsa drivers for issuing
+// raw SCSI CDBs to a physical drive behind the Smart Array controller, see <linux/cciss_ioctl.h>.
+const _CCISS_PASSTHRU = 0xc1107213
+
+// Scan implements transport.Driver. A full implementation issues CCISS_PASSTHRU with an INQUIRY /
+// REPORT PHYSICAL LUNS CDB against each /dev/cciss/cXdY (or hpsa SCSI host) node to enumerate
+// physical drives; lacking real hardware to validate that against, this reference implementation
+// reports the enumeration as not implemented rather than silently claiming zero attached drives.
+func (driver) Scan() ([]transport.Target, error) {
+	return nil, fmt.Errorf("cciss: physical drive enumeration via CCISS_PASSTHRU (0x%x) is not yet implemented", _CCISS_PASSTHRU)
+}
+
+// Open implements transport.Driver. A full implementation would return a scsi.Device that issues
+// ATA SMART CDBs via _CCISS_PASSTHRU against the physical drive addressed by target.
+func (driver) Open(target transport.Target) (scsi.Device, error) {
+	return nil, fmt.Errorf("cciss: SMART passthrough is not yet implemented")
+}
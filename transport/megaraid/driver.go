@@ -0,0 +1,89 @@
+// Copyright 2017-18 Daniel Swarbrick. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package megaraid
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/dswarbrick/smart/drivedb"
+	"github.com/dswarbrick/smart/scsi"
+	"github.com/dswarbrick/smart/smart"
+	"github.com/dswarbrick/smart/transport"
+)
+
+func init() {
+	transport.Register(driver{})
+}
+
+// driver adapts this package's MegasasIoctl/PhysicalDrive API to transport.Driver.
+type driver struct{}
+
+// Name implements transport.Driver.
+func (driver) Name() string { return "megaraid" }
+
+// Scan implements transport.Driver.
+func (driver) Scan() ([]transport.Target, error) {
+	m, err := CreateMegasasIoctl()
+	if err != nil {
+		return nil, err
+	}
+	defer m.Close()
+
+	pds, err := m.ScanDevices()
+	if err != nil {
+		return nil, err
+	}
+
+	targets := make([]transport.Target, len(pds))
+	for i, pd := range pds {
+		targets[i] = transport.Target{Host: pd.Host, Disk: pd.Disk, Model: pd.Model, Serial: pd.Serial, WWN: pd.WWN}
+	}
+
+	return targets, nil
+}
+
+// Open implements transport.Driver.
+func (driver) Open(target transport.Target) (scsi.Device, error) {
+	m, err := OpenMegasasIoctl(target.Host, target.Disk)
+	if err != nil {
+		return nil, err
+	}
+
+	return &device{m: m, target: target}, nil
+}
+
+// device adapts a single MegaRAID physical drive to the scsi.Device interface.
+type device struct {
+	m      *MegasasIoctl
+	target transport.Target
+}
+
+func (d *device) Open() error { return nil }
+
+func (d *device) Close() error { return d.m.Close() }
+
+// errNotImplemented is returned by every SMART-reading method: issuing the MR_DCMD_PD_GET_INFO /
+// CDB passthrough commands needed to read a physical drive's SMART data requires real hardware
+// to validate against, which this reference implementation does not have access to.
+var errNotImplemented = fmt.Errorf("megaraid: SMART passthrough is not yet implemented")
+
+func (d *device) PrintSMART(db *drivedb.DriveDb, w io.Writer) error { return errNotImplemented }
+
+func (d *device) ReadMetrics() (smart.Metrics, error) { return smart.Metrics{}, errNotImplemented }
+
+func (d *device) SMART(db *drivedb.DriveDb) (smart.Report, error) {
+	return smart.Report{}, errNotImplemented
+}
@@ -0,0 +1,79 @@
+// Copyright 2017-18 Daniel Swarbrick. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package megaraid addresses physical drives behind an LSI / Avago / Broadcom MegaRAID SAS
+// controller via the megaraid_sas driver's ioctl interface. It opens the driver's control device
+// only: drive enumeration and SMART passthrough are not performed and return errors.
+//
+//go:build linux
+// +build linux
+
+package megaraid
+
+import (
+	"fmt"
+
+	"golang.org/x/sys/unix"
+)
+
+const megasasIoctlNode = "/dev/megaraid_sas_ioctl_node"
+
+// MegasasIoctl holds an open file descriptor to the megaraid_sas driver's control device.
+type MegasasIoctl struct {
+	fd int
+}
+
+// CreateMegasasIoctl opens the megaraid_sas driver's shared ioctl control device.
+func CreateMegasasIoctl() (*MegasasIoctl, error) {
+	fd, err := unix.Open(megasasIoctlNode, unix.O_RDWR, 0)
+	if err != nil {
+		return nil, fmt.Errorf("megaraid: open %s: %w", megasasIoctlNode, err)
+	}
+	return &MegasasIoctl{fd: fd}, nil
+}
+
+// Close closes the ioctl control device.
+func (m *MegasasIoctl) Close() error {
+	return unix.Close(m.fd)
+}
+
+// errScanNotImplemented is returned by ScanDevices, since issuing MR_DCMD_PD_LIST_QUERY against
+// each host adapter index found under /sys/class/scsi_host, then MR_DCMD_PD_GET_INFO per
+// physical drive to resolve model, serial and WWN, requires real hardware to validate against,
+// which this reference implementation does not have access to. Returning this error rather than
+// a silent empty slice keeps callers (transport.Driver.Scan, -scan, the exporter) from reporting
+// "zero drives found" when enumeration was never actually attempted.
+var errScanNotImplemented = fmt.Errorf("megaraid: physical drive enumeration via MR_DCMD_PD_LIST_QUERY is not yet implemented")
+
+// ScanDevices always returns errScanNotImplemented; no physical drives behind the MegaRAID host
+// adapters are enumerated.
+func (m *MegasasIoctl) ScanDevices() ([]PhysicalDrive, error) {
+	return nil, errScanNotImplemented
+}
+
+// PhysicalDrive describes a single drive attached to a MegaRAID host adapter, including its
+// computed World-Wide Name (see smart.DeviceID) for stable identification across reboots.
+type PhysicalDrive struct {
+	Host   uint16
+	Disk   uint8
+	Model  string
+	Serial string
+	WWN    string
+}
+
+// OpenMegasasIoctl opens a handle suitable for issuing SMART passthrough commands to a single
+// physical drive identified by host adapter index and device ID.
+func OpenMegasasIoctl(host uint16, disk uint8) (*MegasasIoctl, error) {
+	return CreateMegasasIoctl()
+}
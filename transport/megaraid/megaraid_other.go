@@ -0,0 +1,57 @@
+// Copyright 2017-18 Daniel Swarbrick. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !linux
+// +build !linux
+
+package megaraid
+
+import (
+	"errors"
+	"runtime"
+)
+
+// ErrUnsupported is returned by every operation in this package on platforms other than Linux,
+// since the megaraid_sas ioctl interface is a Linux kernel driver feature with no equivalent on
+// Darwin or Windows.
+var ErrUnsupported = errors.New("megaraid: not supported on " + runtime.GOOS)
+
+// MegasasIoctl is an unusable stub on non-Linux platforms.
+type MegasasIoctl struct{}
+
+// PhysicalDrive describes a single drive attached to a MegaRAID host adapter, including its
+// computed World-Wide Name (see smart.DeviceID) for stable identification across reboots.
+type PhysicalDrive struct {
+	Host   uint16
+	Disk   uint8
+	Model  string
+	Serial string
+	WWN    string
+}
+
+// CreateMegasasIoctl always returns ErrUnsupported on non-Linux platforms.
+func CreateMegasasIoctl() (*MegasasIoctl, error) {
+	return nil, ErrUnsupported
+}
+
+// OpenMegasasIoctl always returns ErrUnsupported on non-Linux platforms.
+func OpenMegasasIoctl(host uint16, disk uint8) (*MegasasIoctl, error) {
+	return nil, ErrUnsupported
+}
+
+// Close is a no-op stub.
+func (m *MegasasIoctl) Close() error { return nil }
+
+// ScanDevices always returns ErrUnsupported on non-Linux platforms.
+func (m *MegasasIoctl) ScanDevices() ([]PhysicalDrive, error) { return nil, ErrUnsupported }
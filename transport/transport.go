@@ -0,0 +1,92 @@
+// Copyright 2017-18 Daniel Swarbrick. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package transport defines a registry of pluggable RAID / HBA passthrough drivers, so that
+// cmd/smartctl and the exporter package can address physical drives behind a MegaRAID, Areca,
+// cciss or aacraid host adapter uniformly, without hard-coding a branch per controller family.
+// Individual drivers (see transport/megaraid, transport/areca, transport/cciss,
+// transport/aacraid) register themselves via Register from an init() function; importing a
+// driver package for its side effects is what makes it available here.
+package transport
+
+import (
+	"fmt"
+
+	"github.com/dswarbrick/smart/scsi"
+)
+
+// Target identifies a single physical drive behind a registered Driver.
+type Target struct {
+	// Host is the host adapter / controller index, e.g. the MegaRAID adapter index, or the SCSI
+	// host number for /dev/sg*-based drivers.
+	Host uint16
+
+	// Disk is the target's physical drive / device ID within Host.
+	Disk uint8
+
+	Model  string
+	Serial string
+	WWN    string
+}
+
+// Driver is implemented by every supported RAID / HBA passthrough transport.
+type Driver interface {
+	// Name identifies the driver for the "-raid-device <name><host>/<disk>" command-line syntax,
+	// e.g. "megaraid", "areca", "cciss", "aacraid".
+	Name() string
+
+	// Scan enumerates every physical drive the driver can see across all attached host
+	// adapters.
+	Scan() ([]Target, error)
+
+	// Open returns a scsi.Device for issuing SMART passthrough commands to target.
+	Open(target Target) (scsi.Device, error)
+}
+
+var drivers = map[string]Driver{}
+
+// Register adds d to the set of known drivers, keyed by d.Name(). It is intended to be called
+// from a driver package's init() function.
+func Register(d Driver) {
+	drivers[d.Name()] = d
+}
+
+// Lookup returns the registered driver named name, and whether it was found.
+func Lookup(name string) (Driver, bool) {
+	d, ok := drivers[name]
+	return d, ok
+}
+
+// Drivers returns every registered driver.
+func Drivers() []Driver {
+	all := make([]Driver, 0, len(drivers))
+	for _, d := range drivers {
+		all = append(all, d)
+	}
+	return all
+}
+
+// ParseDeviceSpec parses a "-raid-device" argument of the form "<driver><host>/<disk>" (e.g.
+// "areca0/3") and returns the matching registered driver along with the addressed Target.
+func ParseDeviceSpec(spec string) (Driver, Target, error) {
+	for name, d := range drivers {
+		var host uint16
+		var disk uint8
+		if _, err := fmt.Sscanf(spec, name+"%d/%d", &host, &disk); err == nil {
+			return d, Target{Host: host, Disk: disk}, nil
+		}
+	}
+
+	return nil, Target{}, fmt.Errorf("transport: no registered driver matches device spec %q", spec)
+}
@@ -0,0 +1,64 @@
+// Copyright 2017-18 Daniel Swarbrick. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package transport
+
+import (
+	"testing"
+
+	"github.com/dswarbrick/smart/scsi"
+)
+
+// fakeDriver is a minimal Driver registered solely for exercising ParseDeviceSpec.
+type fakeDriver struct{}
+
+func (fakeDriver) Name() string                            { return "faketransport" }
+func (fakeDriver) Scan() ([]Target, error)                 { return nil, nil }
+func (fakeDriver) Open(target Target) (scsi.Device, error) { return nil, nil }
+
+func init() {
+	Register(fakeDriver{})
+}
+
+func TestParseDeviceSpec(t *testing.T) {
+	tests := []struct {
+		spec       string
+		wantDriver string
+		wantTarget Target
+		wantErr    bool
+	}{
+		{"faketransport0/3", "faketransport", Target{Host: 0, Disk: 3}, false},
+		{"faketransport12/255", "faketransport", Target{Host: 12, Disk: 255}, false},
+		{"nosuchdriver0/3", "", Target{}, true},
+		{"faketransport", "", Target{}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			d, target, err := ParseDeviceSpec(tt.spec)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseDeviceSpec(%q) error = %v, wantErr %v", tt.spec, err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if d.Name() != tt.wantDriver {
+				t.Errorf("ParseDeviceSpec(%q) driver = %q, want %q", tt.spec, d.Name(), tt.wantDriver)
+			}
+			if target != tt.wantTarget {
+				t.Errorf("ParseDeviceSpec(%q) target = %+v, want %+v", tt.spec, target, tt.wantTarget)
+			}
+		})
+	}
+}